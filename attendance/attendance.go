@@ -0,0 +1,249 @@
+// Package attendance builds timesheets from the check-ins of the persons,
+// received by webhook or read from the check-in history:
+//
+//	ts := attendance.NewTimesheet(loc)
+//	for _, r := range records {
+//		ts.AddData(r.Data())
+//	}
+//	for _, day := range ts.Days() {
+//		fmt.Println(day.AliasID, day.Date.Format("2006-01-02"), day.FirstIn, day.LastOut)
+//	}
+package attendance
+
+import (
+	"sort"
+	"time"
+
+	"giautm.dev/hanetai/webhook"
+)
+
+// Checkin is a person recognized at a time.
+type Checkin struct {
+	AliasID    string
+	PersonID   string
+	PersonName string
+	PersonType webhook.PersonType
+	PlaceID    int
+	DeviceID   string
+	Time       time.Time
+}
+
+// FromData returns the check-in of a webhook event, it returns false when
+// the event has no person or no time.
+func FromData(data *webhook.Data) (Checkin, bool) {
+	if data.PersonData == nil {
+		return Checkin{}, false
+	}
+	t, ok := data.EventTime()
+	if !ok {
+		return Checkin{}, false
+	}
+
+	c := Checkin{
+		AliasID:    data.AliasID,
+		PersonID:   data.PersonID,
+		PersonName: data.PersonName,
+		PersonType: data.PersonType,
+		Time:       t,
+	}
+	if data.PlaceData != nil {
+		c.PlaceID = data.PlaceID.Int()
+	}
+	if data.DeviceData != nil {
+		c.DeviceID = data.DeviceID
+	}
+	return c, true
+}
+
+// Gap is a period without check-ins longer than Timesheet.MaxGap.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// Day is the attendance of a person on a day.
+type Day struct {
+	AliasID    string
+	PersonName string
+
+	// PlaceID is the place of the first check-in of the day.
+	PlaceID int
+
+	// Date is the midnight starting the day, in the location of the
+	// timesheet.
+	Date time.Time
+
+	FirstIn  time.Time
+	LastOut  time.Time
+	Checkins []time.Time
+
+	// Presence is the time between FirstIn and LastOut, without the gaps.
+	Presence time.Duration
+	Gaps     []Gap
+}
+
+// Timesheet groups the check-ins per alias ID per local day. The
+// check-ins can be added in any order.
+type Timesheet struct {
+	// Location is the time zone of the days.
+	Location *time.Location
+
+	// DayStart moves the start of the days after midnight, e.g. with 4h the
+	// check-ins before 4am count for the day before.
+	DayStart time.Duration
+
+	// Overnight keeps a check-in in the day of the previous check-in when
+	// it is at most this long after it, even past the start of the next
+	// day, so a night shift is a single day. Zero disables it.
+	Overnight time.Duration
+
+	// MaxShift bounds a day kept by Overnight, a check-in more than MaxShift
+	// after the first check-in of the day starts a new day, so regular
+	// check-ins don't chain the days together. It defaults to 16h.
+	MaxShift time.Duration
+
+	// MaxGap is the longest time between check-ins counted as presence,
+	// longer periods are reported as gaps. Zero counts the whole time
+	// between the first and last check-ins.
+	MaxGap time.Duration
+
+	persons map[string]*person
+}
+
+type person struct {
+	name     string
+	checkins []Checkin
+}
+
+// NewTimesheet returns a timesheet with the days in loc.
+func NewTimesheet(loc *time.Location) *Timesheet {
+	return &Timesheet{
+		Location: loc,
+	}
+}
+
+// Add adds a check-in to the timesheet. It is ignored, and Add returns
+// false, when the person is a stranger or has no alias ID.
+func (t *Timesheet) Add(c Checkin) bool {
+	if c.AliasID == "" || c.PersonType.IsStranger() || c.Time.IsZero() {
+		return false
+	}
+
+	if t.persons == nil {
+		t.persons = map[string]*person{}
+	}
+	p, ok := t.persons[c.AliasID]
+	if !ok {
+		p = &person{}
+		t.persons[c.AliasID] = p
+	}
+	if c.PersonName != "" {
+		p.name = c.PersonName
+	}
+	p.checkins = append(p.checkins, c)
+
+	return true
+}
+
+// AddData adds the check-in of a webhook event, see FromData and Add.
+func (t *Timesheet) AddData(data *webhook.Data) bool {
+	c, ok := FromData(data)
+	if !ok {
+		return false
+	}
+	return t.Add(c)
+}
+
+// Days returns the days of the persons, sorted by alias ID and date.
+func (t *Timesheet) Days() []Day {
+	aliases := make([]string, 0, len(t.persons))
+	for alias := range t.persons {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var days []Day
+	for _, alias := range aliases {
+		days = append(days, t.days(alias, t.persons[alias])...)
+	}
+	return days
+}
+
+func (t *Timesheet) days(alias string, p *person) []Day {
+	checkins := append([]Checkin(nil), p.checkins...)
+	sort.Slice(checkins, func(i, j int) bool { return checkins[i].Time.Before(checkins[j].Time) })
+
+	var (
+		days []Day
+		cur  *Day
+	)
+	for _, checkin := range checkins {
+		c := checkin.Time.In(t.location())
+		if cur != nil {
+			last := cur.Checkins[len(cur.Checkins)-1]
+			if c.Equal(last) {
+				continue
+			}
+			overnight := t.Overnight > 0 && c.Sub(last) <= t.Overnight &&
+				c.Sub(cur.Checkins[0]) <= t.maxShift()
+			if t.date(c).Equal(cur.Date) || overnight {
+				cur.Checkins = append(cur.Checkins, c)
+				continue
+			}
+			days = append(days, t.finish(*cur))
+		}
+
+		cur = &Day{
+			AliasID:    alias,
+			PersonName: p.name,
+			PlaceID:    checkin.PlaceID,
+			Date:       t.date(c),
+			Checkins:   []time.Time{c},
+		}
+	}
+	if cur != nil {
+		days = append(days, t.finish(*cur))
+	}
+	return days
+}
+
+func (t *Timesheet) finish(d Day) Day {
+	d.FirstIn = d.Checkins[0]
+	d.LastOut = d.Checkins[len(d.Checkins)-1]
+	d.Presence = d.LastOut.Sub(d.FirstIn)
+
+	if t.MaxGap > 0 {
+		for i := 1; i < len(d.Checkins); i++ {
+			g := Gap{Start: d.Checkins[i-1], End: d.Checkins[i]}
+			if g.Duration() > t.MaxGap {
+				d.Gaps = append(d.Gaps, g)
+				d.Presence -= g.Duration()
+			}
+		}
+	}
+	return d
+}
+
+// date returns the midnight of the day of c.
+func (t *Timesheet) date(c time.Time) time.Time {
+	c = c.Add(-t.DayStart).In(t.location())
+	return time.Date(c.Year(), c.Month(), c.Day(), 0, 0, 0, 0, t.location())
+}
+
+func (t *Timesheet) maxShift() time.Duration {
+	if t.MaxShift <= 0 {
+		return 16 * time.Hour
+	}
+	return t.MaxShift
+}
+
+func (t *Timesheet) location() *time.Location {
+	if t.Location == nil {
+		return time.Local
+	}
+	return t.Location
+}
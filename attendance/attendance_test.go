@@ -0,0 +1,181 @@
+package attendance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"giautm.dev/hanetai/webhook"
+)
+
+var ict = time.FixedZone("ICT", 7*60*60)
+
+func at(day, hour, min int) time.Time {
+	return time.Date(2021, 7, day, hour, min, 0, 0, ict)
+}
+
+func TestTimesheet_Days(t *testing.T) {
+	type want struct {
+		alias    string
+		date     time.Time
+		firstIn  time.Time
+		lastOut  time.Time
+		presence time.Duration
+		gaps     int
+	}
+	tests := []struct {
+		name     string
+		ts       *Timesheet
+		checkins []Checkin
+		want     []want
+	}{
+		{
+			name: "first in and last out per day",
+			ts:   NewTimesheet(ict),
+			checkins: []Checkin{
+				{AliasID: "A", Time: at(26, 17, 30)},
+				{AliasID: "A", Time: at(26, 8, 0)},
+				{AliasID: "A", Time: at(26, 12, 0)},
+				{AliasID: "A", Time: at(26, 12, 0)},
+				{AliasID: "A", Time: at(27, 8, 15)},
+				{AliasID: "B", Time: at(26, 9, 0)},
+			},
+			want: []want{
+				{alias: "A", date: at(26, 0, 0), firstIn: at(26, 8, 0), lastOut: at(26, 17, 30), presence: 9*time.Hour + 30*time.Minute},
+				{alias: "A", date: at(27, 0, 0), firstIn: at(27, 8, 15), lastOut: at(27, 8, 15)},
+				{alias: "B", date: at(26, 0, 0), firstIn: at(26, 9, 0), lastOut: at(26, 9, 0)},
+			},
+		},
+		{
+			name: "days in the time zone of the timesheet",
+			ts:   NewTimesheet(time.UTC),
+			checkins: []Checkin{
+				{AliasID: "A", Time: at(26, 6, 0)},
+				{AliasID: "A", Time: at(26, 8, 0)},
+			},
+			want: []want{
+				{alias: "A", date: time.Date(2021, 7, 25, 0, 0, 0, 0, time.UTC), firstIn: at(26, 6, 0), lastOut: at(26, 6, 0)},
+				{alias: "A", date: time.Date(2021, 7, 26, 0, 0, 0, 0, time.UTC), firstIn: at(26, 8, 0), lastOut: at(26, 8, 0)},
+			},
+		},
+		{
+			name: "gaps",
+			ts:   &Timesheet{Location: ict, MaxGap: time.Hour},
+			checkins: []Checkin{
+				{AliasID: "A", Time: at(26, 8, 0)},
+				{AliasID: "A", Time: at(26, 8, 30)},
+				{AliasID: "A", Time: at(26, 11, 0)},
+				{AliasID: "A", Time: at(26, 11, 45)},
+			},
+			want: []want{
+				{alias: "A", date: at(26, 0, 0), firstIn: at(26, 8, 0), lastOut: at(26, 11, 45), presence: 75 * time.Minute, gaps: 1},
+			},
+		},
+		{
+			name: "overnight shift",
+			ts:   &Timesheet{Location: ict, Overnight: 10 * time.Hour},
+			checkins: []Checkin{
+				{AliasID: "A", Time: at(26, 22, 0)},
+				{AliasID: "A", Time: at(27, 1, 0)},
+				{AliasID: "A", Time: at(27, 6, 0)},
+				{AliasID: "A", Time: at(27, 22, 0)},
+			},
+			want: []want{
+				{alias: "A", date: at(26, 0, 0), firstIn: at(26, 22, 0), lastOut: at(27, 6, 0), presence: 8 * time.Hour},
+				{alias: "A", date: at(27, 0, 0), firstIn: at(27, 22, 0), lastOut: at(27, 22, 0)},
+			},
+		},
+		{
+			name: "overnight bounded by max shift",
+			ts:   &Timesheet{Location: ict, Overnight: 12 * time.Hour},
+			checkins: []Checkin{
+				{AliasID: "A", Time: at(26, 8, 0)},
+				{AliasID: "A", Time: at(26, 17, 0)},
+				{AliasID: "A", Time: at(27, 4, 0)},
+				{AliasID: "A", Time: at(27, 8, 0)},
+				{AliasID: "A", Time: at(27, 17, 0)},
+			},
+			want: []want{
+				{alias: "A", date: at(26, 0, 0), firstIn: at(26, 8, 0), lastOut: at(26, 17, 0), presence: 9 * time.Hour},
+				{alias: "A", date: at(27, 0, 0), firstIn: at(27, 4, 0), lastOut: at(27, 17, 0), presence: 13 * time.Hour},
+			},
+		},
+		{
+			name: "day start",
+			ts:   &Timesheet{Location: ict, DayStart: 4 * time.Hour},
+			checkins: []Checkin{
+				{AliasID: "A", Time: at(26, 20, 0)},
+				{AliasID: "A", Time: at(27, 3, 0)},
+			},
+			want: []want{
+				{alias: "A", date: at(26, 0, 0), firstIn: at(26, 20, 0), lastOut: at(27, 3, 0), presence: 7 * time.Hour},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range tt.checkins {
+				tt.ts.Add(c)
+			}
+
+			var got []want
+			for _, d := range tt.ts.Days() {
+				got = append(got, want{
+					alias:    d.AliasID,
+					date:     d.Date,
+					firstIn:  d.FirstIn.In(ict),
+					lastOut:  d.LastOut.In(ict),
+					presence: d.Presence,
+					gaps:     len(d.Gaps),
+				})
+			}
+			for i := range got {
+				if i < len(tt.want) && !got[i].date.Equal(tt.want[i].date) {
+					t.Errorf("Days()[%d].Date = %v, want %v", i, got[i].date, tt.want[i].date)
+				}
+				if i < len(tt.want) {
+					got[i].date = tt.want[i].date
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Days() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimesheet_AddData(t *testing.T) {
+	ts := NewTimesheet(ict)
+	event := func(alias string, personType webhook.PersonType) *webhook.Data {
+		return &webhook.Data{
+			DataType:   webhook.DataLog,
+			EventData:  &webhook.EventData{Date: "2021-07-26 08:00:00"},
+			PersonData: &webhook.PersonData{AliasID: alias, PersonName: "Nguyễn Văn A", PersonType: personType},
+			PlaceData:  &webhook.PlaceData{PlaceID: 1542},
+		}
+	}
+
+	tests := []struct {
+		name string
+		data *webhook.Data
+		want bool
+	}{
+		{name: "employee", data: event("A", webhook.PersonEmployee), want: true},
+		{name: "stranger", data: event("A", webhook.PersonStranger), want: false},
+		{name: "without alias", data: event("", webhook.PersonEmployee), want: false},
+		{name: "without person", data: &webhook.Data{EventData: &webhook.EventData{Time: 1}}, want: false},
+		{name: "without time", data: &webhook.Data{PersonData: &webhook.PersonData{AliasID: "A"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ts.AddData(tt.data); got != tt.want {
+				t.Errorf("Timesheet.AddData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	days := ts.Days()
+	if len(days) != 1 || days[0].PersonName != "Nguyễn Văn A" || !days[0].FirstIn.Equal(at(26, 8, 0)) {
+		t.Errorf("Timesheet.Days() = %+v, want a day of A", days)
+	}
+}
@@ -0,0 +1,229 @@
+package attendance
+
+import (
+	"sort"
+	"time"
+)
+
+// Status classifies a day of a person against their policy.
+type Status string
+
+const (
+	StatusOnTime    Status = "on-time"
+	StatusLate      Status = "late"
+	StatusLeftEarly Status = "left-early"
+	StatusAbsent    Status = "absent"
+	StatusOvertime  Status = "overtime"
+	StatusRestDay   Status = "rest-day"
+	StatusHoliday   Status = "holiday"
+)
+
+// Employee is a person expected to work, so the days without check-ins are
+// evaluated as absent.
+type Employee struct {
+	AliasID string
+	Name    string
+	PlaceID int
+}
+
+// Result is the evaluation of a day of a person.
+type Result struct {
+	AliasID    string
+	PersonName string
+	PlaceID    int
+	Date       Date
+	Policy     string
+
+	// Statuses is on-time, or any of late, left-early and overtime, or
+	// absent. Rest days and holidays are overtime when worked.
+	Statuses []Status
+
+	LateMinutes     int
+	EarlyMinutes    int
+	OvertimeMinutes int
+	WorkedMinutes   int
+
+	// Day is the attendance of the day, nil when the person didn't come.
+	Day *Day
+}
+
+// Is reports whether the day has the status.
+func (r *Result) Is(s Status) bool {
+	for _, v := range r.Statuses {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluator classifies the days of a timesheet against the policies of
+// the persons.
+type Evaluator struct {
+	Config *Config
+
+	// Location is the time zone of the shifts, it should be the one of the
+	// timesheet.
+	Location *time.Location
+}
+
+// Evaluate returns the results of the employees for each day from from to
+// to, sorted by alias ID and date. When employees is nil, the persons of
+// the days are evaluated. The persons without a policy are skipped.
+//
+// Without a Config, the days with check-ins are returned with their worked
+// time and no status.
+func (e *Evaluator) Evaluate(days []Day, employees []Employee, from, to Date) []Result {
+	if e.Config == nil {
+		var results []Result
+		for i := range days {
+			d := &days[i]
+			if date := DateOf(d.Date); !after(from, date) && !after(date, to) {
+				results = append(results, Result{
+					AliasID:       d.AliasID,
+					PersonName:    d.PersonName,
+					PlaceID:       d.PlaceID,
+					Date:          date,
+					WorkedMinutes: minutes(d.Presence),
+					Day:           d,
+				})
+			}
+		}
+		return results
+	}
+
+	byAlias := map[string]map[Date]*Day{}
+	for i := range days {
+		d := &days[i]
+		if byAlias[d.AliasID] == nil {
+			byAlias[d.AliasID] = map[Date]*Day{}
+		}
+		byAlias[d.AliasID][DateOf(d.Date)] = d
+	}
+
+	if employees == nil {
+		seen := map[string]bool{}
+		for _, d := range days {
+			if !seen[d.AliasID] {
+				seen[d.AliasID] = true
+				employees = append(employees, Employee{AliasID: d.AliasID, Name: d.PersonName, PlaceID: d.PlaceID})
+			}
+		}
+	}
+	employees = append([]Employee(nil), employees...)
+	sort.SliceStable(employees, func(i, j int) bool { return employees[i].AliasID < employees[j].AliasID })
+
+	var results []Result
+	for _, emp := range employees {
+		name, p := e.Config.Policy(emp.PlaceID, emp.AliasID)
+		if p == nil {
+			continue
+		}
+
+		for date := from; !after(date, to); date = nextDate(date) {
+			r := Result{
+				AliasID:    emp.AliasID,
+				PersonName: emp.Name,
+				PlaceID:    emp.PlaceID,
+				Date:       date,
+				Policy:     name,
+				Day:        byAlias[emp.AliasID][date],
+			}
+			if r.Day != nil && r.PersonName == "" {
+				r.PersonName = r.Day.PersonName
+			}
+			e.evaluate(p, &r)
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func (e *Evaluator) evaluate(p *Policy, r *Result) {
+	var worked time.Duration
+	if d := r.Day; d != nil {
+		if worked = d.Presence - time.Duration(p.Break); worked < 0 {
+			worked = 0
+		}
+	}
+	r.WorkedMinutes = minutes(worked)
+
+	loc := e.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	midnight := time.Date(r.Date.Year, r.Date.Month, r.Date.Day, 0, 0, 0, 0, loc)
+
+	off := StatusRestDay
+	if e.Config.IsHoliday(r.Date) {
+		off = StatusHoliday
+	} else if !p.IsRestDay(midnight.Weekday()) {
+		off = ""
+	}
+	if off != "" {
+		r.Statuses = []Status{off}
+		if worked > 0 {
+			r.Statuses = append(r.Statuses, StatusOvertime)
+			r.OvertimeMinutes = minutes(worked)
+		}
+		return
+	}
+
+	d := r.Day
+	if d == nil {
+		r.Statuses = []Status{StatusAbsent}
+		return
+	}
+
+	var late, early, overtime time.Duration
+	if p.Flexible {
+		if p.Start > 0 {
+			late = d.FirstIn.Sub(midnight.Add(time.Duration(p.Start)))
+		}
+		early = time.Duration(p.Hours) - worked
+		overtime = worked - time.Duration(p.Hours)
+	} else {
+		start := midnight.Add(time.Duration(p.Start))
+		end := midnight.Add(time.Duration(p.End))
+		if p.EndsNextDay() {
+			end = end.Add(24 * time.Hour)
+		}
+		late = d.FirstIn.Sub(start)
+		early = end.Sub(d.LastOut)
+		overtime = d.LastOut.Sub(end)
+	}
+
+	if late > time.Duration(p.LateGrace) {
+		r.Statuses = append(r.Statuses, StatusLate)
+		r.LateMinutes = minutes(late)
+	}
+	if early > time.Duration(p.EarlyGrace) {
+		r.Statuses = append(r.Statuses, StatusLeftEarly)
+		r.EarlyMinutes = minutes(early)
+	}
+	if overtime > time.Duration(p.OvertimeAfter) {
+		r.Statuses = append(r.Statuses, StatusOvertime)
+		r.OvertimeMinutes = minutes(overtime)
+	}
+	if len(r.Statuses) == 0 {
+		r.Statuses = []Status{StatusOnTime}
+	}
+}
+
+func minutes(d time.Duration) int {
+	return int(d / time.Minute)
+}
+
+func nextDate(d Date) Date {
+	return DateOf(time.Date(d.Year, d.Month, d.Day+1, 0, 0, 0, 0, time.UTC))
+}
+
+func after(a, b Date) bool {
+	if a.Year != b.Year {
+		return a.Year > b.Year
+	}
+	if a.Month != b.Month {
+		return a.Month > b.Month
+	}
+	return a.Day > b.Day
+}
@@ -0,0 +1,115 @@
+package attendance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	c, err := ParseConfig([]byte(configYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Holidays = append(c.Holidays, Date{2021, time.July, 29})
+
+	ts := &Timesheet{Location: ict, Overnight: 10 * time.Hour}
+	for _, ck := range []Checkin{
+		// office, Monday: late, left early.
+		{AliasID: "A", PlaceID: 1, Time: at(26, 8, 50)},
+		{AliasID: "A", PlaceID: 1, Time: at(26, 17, 0)},
+		// office, Tuesday: within the grace period, overtime.
+		{AliasID: "A", PlaceID: 1, Time: at(27, 8, 35)},
+		{AliasID: "A", PlaceID: 1, Time: at(27, 19, 0)},
+		// office, Wednesday: absent. Thursday: holiday worked.
+		{AliasID: "A", PlaceID: 1, Time: at(29, 9, 0)},
+		{AliasID: "A", PlaceID: 1, Time: at(29, 12, 0)},
+		// flex: 7h instead of 8h.
+		{AliasID: "B", PlaceID: 1542, Time: at(26, 10, 0)},
+		{AliasID: "B", PlaceID: 1542, Time: at(26, 17, 0)},
+		// night shift.
+		{AliasID: "VCFL1231231", PlaceID: 1542, Time: at(26, 21, 55)},
+		{AliasID: "VCFL1231231", PlaceID: 1542, Time: at(27, 6, 5)},
+	} {
+		ts.Add(ck)
+	}
+
+	e := &Evaluator{Config: c, Location: ict}
+	results := e.Evaluate(ts.Days(), nil, Date{2021, time.July, 26}, Date{2021, time.July, 31})
+
+	type want struct {
+		alias    string
+		day      int
+		statuses []Status
+		late     int
+		early    int
+		overtime int
+		worked   int
+	}
+	var got []want
+	for _, r := range results {
+		got = append(got, want{r.AliasID, r.Date.Day, r.Statuses, r.LateMinutes, r.EarlyMinutes, r.OvertimeMinutes, r.WorkedMinutes})
+	}
+
+	wants := []want{
+		{"A", 26, []Status{StatusLate, StatusLeftEarly}, 20, 30, 0, 7*60 + 10},
+		{"A", 27, []Status{StatusOvertime}, 0, 0, 90, 9*60 + 25},
+		{"A", 28, []Status{StatusAbsent}, 0, 0, 0, 0},
+		{"A", 29, []Status{StatusHoliday, StatusOvertime}, 0, 0, 120, 120},
+		{"A", 30, []Status{StatusAbsent}, 0, 0, 0, 0},
+		{"A", 31, []Status{StatusRestDay}, 0, 0, 0, 0},
+		{"B", 26, []Status{StatusLeftEarly}, 0, 60, 0, 7 * 60},
+		{"B", 27, []Status{StatusAbsent}, 0, 0, 0, 0},
+		{"B", 28, []Status{StatusAbsent}, 0, 0, 0, 0},
+		{"B", 29, []Status{StatusHoliday}, 0, 0, 0, 0},
+		{"B", 30, []Status{StatusAbsent}, 0, 0, 0, 0},
+		{"B", 31, []Status{StatusAbsent}, 0, 0, 0, 0},
+	}
+	for d := 26; d <= 31; d++ {
+		w := want{"VCFL1231231", d, []Status{StatusAbsent}, 0, 0, 0, 0}
+		switch d {
+		case 26:
+			w.statuses, w.overtime, w.worked = []Status{StatusOvertime}, 5, 8*60+10
+		case 29:
+			w.statuses = []Status{StatusHoliday}
+		}
+		wants = append(wants, w)
+	}
+
+	if !reflect.DeepEqual(got, wants) {
+		for i := range got {
+			if i >= len(wants) || !reflect.DeepEqual(got[i], wants[i]) {
+				t.Errorf("Evaluate()[%d] = %+v, want %+v", i, got[i], wants[i])
+			}
+		}
+		if len(got) != len(wants) {
+			t.Errorf("Evaluate() returned %d results, want %d", len(got), len(wants))
+		}
+	}
+}
+
+func TestEvaluator_EvaluateEmployees(t *testing.T) {
+	c := &Config{Policies: map[string]*Policy{"office": {Start: Clock(8 * time.Hour), End: Clock(17 * time.Hour)}}, Default: "office"}
+	e := &Evaluator{Config: c, Location: ict}
+
+	results := e.Evaluate(nil, []Employee{{AliasID: "A", Name: "Nguyễn Văn A"}}, Date{2021, time.July, 26}, Date{2021, time.July, 26})
+	if len(results) != 1 || !results[0].Is(StatusAbsent) || results[0].PersonName != "Nguyễn Văn A" {
+		t.Errorf("Evaluate() = %+v, want A absent", results)
+	}
+}
+
+func TestEvaluator_EvaluateWithoutConfig(t *testing.T) {
+	ts := NewTimesheet(ict)
+	ts.Add(Checkin{AliasID: "A", Time: at(25, 8, 0)})
+	ts.Add(Checkin{AliasID: "A", Time: at(26, 8, 0)})
+	ts.Add(Checkin{AliasID: "A", Time: at(26, 17, 0)})
+
+	e := &Evaluator{Location: ict}
+	results := e.Evaluate(ts.Days(), nil, Date{2021, time.July, 26}, Date{2021, time.July, 31})
+	if len(results) != 1 {
+		t.Fatalf("Evaluate() returned %d results, want 1", len(results))
+	}
+	if r := results[0]; r.Date != (Date{2021, time.July, 26}) || r.WorkedMinutes != 9*60 || r.Statuses != nil {
+		t.Errorf("Evaluate() = %+v, want 9h worked on 2021-07-26 without status", r)
+	}
+}
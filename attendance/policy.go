@@ -0,0 +1,290 @@
+package attendance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Clock is a time of day, written as "08:30".
+type Clock time.Duration
+
+func (c Clock) String() string {
+	d := time.Duration(c)
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(b []byte) error {
+	t, err := time.Parse("15:04", string(b))
+	if err != nil {
+		return fmt.Errorf("invalid time of day %q", b)
+	}
+
+	*c = Clock(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	return nil
+}
+
+// Duration is a duration written as "1h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// Weekday is a day of the week, written as "sunday".
+type Weekday time.Weekday
+
+func (d Weekday) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(time.Weekday(d).String())), nil
+}
+
+func (d *Weekday) UnmarshalText(b []byte) error {
+	for i := time.Sunday; i <= time.Saturday; i++ {
+		if strings.EqualFold(i.String(), string(b)) || strings.EqualFold(i.String()[:3], string(b)) {
+			*d = Weekday(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid weekday %q", b)
+}
+
+// Date is a calendar day, written as "2021-09-02".
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the calendar day of t, in the location of t.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{y, m, d}
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(b []byte) error {
+	t, err := time.Parse("2006-01-02", string(b))
+	if err != nil {
+		return fmt.Errorf("invalid date %q", b)
+	}
+
+	*d = DateOf(t)
+	return nil
+}
+
+// Policy is the schedule the days of a person are evaluated against.
+//
+// A fixed shift runs from Start to End, ending the next day when End is
+// before Start, they must differ. A flexible policy requires Hours of work a day, with an
+// optional latest Start.
+type Policy struct {
+	Start Clock `json:"start,omitempty" yaml:"start,omitempty"`
+	End   Clock `json:"end,omitempty" yaml:"end,omitempty"`
+
+	Flexible bool     `json:"flexible,omitempty" yaml:"flexible,omitempty"`
+	Hours    Duration `json:"hours,omitempty" yaml:"hours,omitempty"`
+
+	// LateGrace and EarlyGrace are tolerated before a day is late or left
+	// early.
+	LateGrace  Duration `json:"lateGrace,omitempty" yaml:"lateGrace,omitempty"`
+	EarlyGrace Duration `json:"earlyGrace,omitempty" yaml:"earlyGrace,omitempty"`
+
+	// Break is the unpaid break, not counted as worked time.
+	Break Duration `json:"break,omitempty" yaml:"break,omitempty"`
+
+	// OvertimeAfter is the time worked past the end of the shift, or past
+	// Hours, before it counts as overtime.
+	OvertimeAfter Duration `json:"overtimeAfter,omitempty" yaml:"overtimeAfter,omitempty"`
+
+	// RestDays are the days of the week without work.
+	RestDays []Weekday `json:"restDays,omitempty" yaml:"restDays,omitempty"`
+}
+
+// EndsNextDay reports whether the policy is a fixed shift ending the day
+// after it starts.
+func (p *Policy) EndsNextDay() bool {
+	return !p.Flexible && p.End <= p.Start
+}
+
+// IsRestDay reports whether the day of the week is a rest day.
+func (p *Policy) IsRestDay(d time.Weekday) bool {
+	for _, r := range p.RestDays {
+		if time.Weekday(r) == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is a set of policies and the persons they apply to:
+//
+//	policies:
+//	  office:
+//	    start: "08:30"
+//	    end: "17:30"
+//	    lateGrace: 10m
+//	    break: 1h
+//	    restDays: [saturday, sunday]
+//	  night:
+//	    start: "22:00"
+//	    end: "06:00"
+//	holidays: ["2021-09-02"]
+//	default: office
+//	places:
+//	  1542: office
+//	aliases:
+//	  VCFL1231231: night
+type Config struct {
+	Policies map[string]*Policy `json:"policies" yaml:"policies"`
+
+	// Holidays are the days without work, for all the policies.
+	Holidays []Date `json:"holidays,omitempty" yaml:"holidays,omitempty"`
+
+	// Default is the policy of the persons without a place or alias
+	// policy.
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Places and Aliases are the names of the policies of the places and
+	// of the persons, the policy of a person takes precedence.
+	Places  map[int]string    `json:"places,omitempty" yaml:"places,omitempty"`
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// LoadConfig reads the config of a YAML or JSON file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := ParseConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseConfig parses a YAML or JSON config, and checks the policies it
+// refers to exist.
+func ParseConfig(b []byte) (*Config, error) {
+	var c Config
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&c); err != nil {
+			return nil, err
+		}
+	} else {
+		d := yaml.NewDecoder(bytes.NewReader(b))
+		d.KnownFields(true)
+		if err := d.Decode(&c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	check := func(name, of string) error {
+		if _, ok := c.Policies[name]; !ok {
+			return fmt.Errorf("unknown policy %q of %s", name, of)
+		}
+		return nil
+	}
+
+	if c.Default != "" {
+		if err := check(c.Default, "default"); err != nil {
+			return err
+		}
+	}
+	for id, name := range c.Places {
+		if err := check(name, fmt.Sprintf("place %d", id)); err != nil {
+			return err
+		}
+	}
+	for alias, name := range c.Aliases {
+		if err := check(name, fmt.Sprintf("alias %s", alias)); err != nil {
+			return err
+		}
+	}
+	for name, p := range c.Policies {
+		if p == nil {
+			return fmt.Errorf("empty policy %q", name)
+		}
+		if p.Flexible && p.Hours <= 0 {
+			return fmt.Errorf("flexible policy %q requires hours", name)
+		}
+		if !p.Flexible && p.Start == p.End {
+			return fmt.Errorf("fixed policy %q requires a start and an end that differ", name)
+		}
+	}
+	return nil
+}
+
+// EndsNextDay reports whether any of the policies ends the day after it
+// starts, see Policy.EndsNextDay.
+func (c *Config) EndsNextDay() bool {
+	for _, p := range c.Policies {
+		if p.EndsNextDay() {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy returns the name and policy of a person, by alias ID then by
+// place, or the default policy. It returns nil when there is none.
+func (c *Config) Policy(placeID int, aliasID string) (string, *Policy) {
+	name, ok := c.Aliases[aliasID]
+	if !ok {
+		name, ok = c.Places[placeID]
+	}
+	if !ok {
+		name = c.Default
+	}
+
+	if p, ok := c.Policies[name]; ok {
+		return name, p
+	}
+	return "", nil
+}
+
+// IsHoliday reports whether d is a holiday.
+func (c *Config) IsHoliday(d Date) bool {
+	for _, h := range c.Holidays {
+		if h == d {
+			return true
+		}
+	}
+	return false
+}
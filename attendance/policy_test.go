@@ -0,0 +1,136 @@
+package attendance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const configYAML = `
+policies:
+  office:
+    start: "08:30"
+    end: "17:30"
+    lateGrace: 10m
+    break: 1h
+    restDays: [saturday, sun]
+  flex:
+    flexible: true
+    hours: 8h
+  night:
+    start: "22:00"
+    end: "06:00"
+holidays: ["2021-09-02"]
+default: office
+places:
+  1542: flex
+aliases:
+  VCFL1231231: night
+`
+
+const configJSON = `{
+  "policies": {
+    "office": {"start": "08:30", "end": "17:30", "lateGrace": "10m", "break": "1h", "restDays": ["saturday", "sun"]},
+    "flex": {"flexible": true, "hours": "8h"},
+    "night": {"start": "22:00", "end": "06:00"}
+  },
+  "holidays": ["2021-09-02"],
+  "default": "office",
+  "places": {"1542": "flex"},
+  "aliases": {"VCFL1231231": "night"}
+}`
+
+func TestParseConfig(t *testing.T) {
+	want := &Config{
+		Policies: map[string]*Policy{
+			"office": {
+				Start:     Clock(8*time.Hour + 30*time.Minute),
+				End:       Clock(17*time.Hour + 30*time.Minute),
+				LateGrace: Duration(10 * time.Minute),
+				Break:     Duration(time.Hour),
+				RestDays:  []Weekday{Weekday(time.Saturday), Weekday(time.Sunday)},
+			},
+			"flex":  {Flexible: true, Hours: Duration(8 * time.Hour)},
+			"night": {Start: Clock(22 * time.Hour), End: Clock(6 * time.Hour)},
+		},
+		Holidays: []Date{{2021, time.September, 2}},
+		Default:  "office",
+		Places:   map[int]string{1542: "flex"},
+		Aliases:  map[string]string{"VCFL1231231": "night"},
+	}
+
+	for name, b := range map[string]string{"yaml": configYAML, "json": configJSON} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseConfig([]byte(b))
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseConfig() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown default":    "policies: {office: {start: '08:00', end: '17:00'}}\ndefault: shop",
+		"unknown alias":      "policies: {office: {start: '08:00', end: '17:00'}}\naliases: {A: shop}",
+		"fixed no start/end": "policies: {office: {restDays: [sunday]}}",
+		"fixed start is end": "policies: {office: {start: '08:00', end: '08:00'}}",
+		"flexible no hours":  "policies: {flex: {flexible: true}}",
+		"invalid clock":      "policies: {office: {start: '8am'}}",
+		"invalid weekday":    "policies: {office: {start: '08:00', end: '17:00', restDays: [someday]}}",
+		"unknown field":      "policies: {office: {begin: '08:00'}}",
+		"unknown JSON field": `{"policies": {}, "shifts": {}}`,
+	}
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseConfig([]byte(b)); err == nil {
+				t.Errorf("ParseConfig() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestConfig_Policy(t *testing.T) {
+	c, err := ParseConfig([]byte(configYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		placeID int
+		aliasID string
+		want    string
+	}{
+		{placeID: 1542, aliasID: "VCFL1231231", want: "night"},
+		{placeID: 1542, aliasID: "852576", want: "flex"},
+		{placeID: 1, aliasID: "852576", want: "office"},
+	}
+	for _, tt := range tests {
+		if got, _ := c.Policy(tt.placeID, tt.aliasID); got != tt.want {
+			t.Errorf("Config.Policy(%v, %v) = %v, want %v", tt.placeID, tt.aliasID, got, tt.want)
+		}
+	}
+
+	c.Default = ""
+	if name, p := c.Policy(1, "852576"); name != "" || p != nil {
+		t.Errorf("Config.Policy() = %v, %v, want none", name, p)
+	}
+}
+
+func TestConfig_EndsNextDay(t *testing.T) {
+	c, err := ParseConfig([]byte(configYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.EndsNextDay() {
+		t.Errorf("Config.EndsNextDay() = false, want true")
+	}
+
+	delete(c.Policies, "night")
+	if c.EndsNextDay() {
+		t.Errorf("Config.EndsNextDay() without the night shift = true, want false")
+	}
+}
@@ -0,0 +1,71 @@
+package attendance
+
+// Summary is the total of the results of a person.
+type Summary struct {
+	AliasID    string
+	PersonName string
+	Policy     string
+
+	// Days is the number of days evaluated, Present the number of days
+	// with check-ins.
+	Days    int
+	Present int
+
+	// Absent, Late, LeftEarly and Overtime are the number of days with the
+	// status.
+	Absent    int
+	Late      int
+	LeftEarly int
+	Overtime  int
+
+	LateMinutes     int
+	EarlyMinutes    int
+	OvertimeMinutes int
+	WorkedMinutes   int
+}
+
+// Summarize returns the summary of each person of the results, in the
+// order of their first result.
+func Summarize(results []Result) []Summary {
+	var (
+		summaries []Summary
+		index     = map[string]int{}
+	)
+	for _, r := range results {
+		i, ok := index[r.AliasID]
+		if !ok {
+			i = len(summaries)
+			index[r.AliasID] = i
+			summaries = append(summaries, Summary{
+				AliasID: r.AliasID,
+				Policy:  r.Policy,
+			})
+		}
+
+		s := &summaries[i]
+		if s.PersonName == "" {
+			s.PersonName = r.PersonName
+		}
+		s.Days++
+		if r.Day != nil {
+			s.Present++
+		}
+		for _, st := range r.Statuses {
+			switch st {
+			case StatusAbsent:
+				s.Absent++
+			case StatusLate:
+				s.Late++
+			case StatusLeftEarly:
+				s.LeftEarly++
+			case StatusOvertime:
+				s.Overtime++
+			}
+		}
+		s.LateMinutes += r.LateMinutes
+		s.EarlyMinutes += r.EarlyMinutes
+		s.OvertimeMinutes += r.OvertimeMinutes
+		s.WorkedMinutes += r.WorkedMinutes
+	}
+	return summaries
+}
@@ -0,0 +1,26 @@
+package attendance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	day := &Day{}
+	results := []Result{
+		{AliasID: "A", Policy: "office", Date: Date{2021, time.July, 26}, Day: day, Statuses: []Status{StatusLate, StatusLeftEarly}, LateMinutes: 20, EarlyMinutes: 30, WorkedMinutes: 430},
+		{AliasID: "A", PersonName: "Nguyễn Văn A", Policy: "office", Date: Date{2021, time.July, 27}, Day: day, Statuses: []Status{StatusOvertime}, OvertimeMinutes: 90, WorkedMinutes: 565},
+		{AliasID: "A", Policy: "office", Date: Date{2021, time.July, 28}, Statuses: []Status{StatusAbsent}},
+		{AliasID: "A", Policy: "office", Date: Date{2021, time.July, 31}, Statuses: []Status{StatusRestDay}},
+		{AliasID: "B", PersonName: "B", Policy: "flex", Date: Date{2021, time.July, 26}, Day: day, Statuses: []Status{StatusOnTime}, WorkedMinutes: 480},
+	}
+
+	want := []Summary{
+		{AliasID: "A", PersonName: "Nguyễn Văn A", Policy: "office", Days: 4, Present: 2, Absent: 1, Late: 1, LeftEarly: 1, Overtime: 1, LateMinutes: 20, EarlyMinutes: 30, OvertimeMinutes: 90, WorkedMinutes: 995},
+		{AliasID: "B", PersonName: "B", Policy: "flex", Days: 1, Present: 1, WorkedMinutes: 480},
+	}
+	if got := Summarize(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package hanetai
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"giautm.dev/hanetai/webhook"
+)
+
+// CheckinRecord is a recognition stored by Hanet, it shares the person,
+// device and place fields with the live webhook events.
+type CheckinRecord struct {
+	webhook.PersonData
+	webhook.DeviceData
+	webhook.PlaceData
+
+	Title       string `json:"title"`
+	CheckinTime int64  `json:"checkinTime"`
+	Date        string `json:"date"`
+}
+
+func (r *CheckinRecord) UnmarshalJSON(data []byte) error {
+	type record CheckinRecord
+	if err := json.Unmarshal(data, (*record)(r)); err != nil {
+		return err
+	}
+
+	// The history API names some fields differently from the webhook.
+	var aux struct {
+		Avatar string          `json:"avatar"`
+		Type   json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if r.DetectedImageURL == "" {
+		r.DetectedImageURL = aux.Avatar
+	}
+	if r.PersonType == "" && len(aux.Type) > 0 {
+		var t webhook.IntID
+		if err := t.UnmarshalJSON(aux.Type); err == nil {
+			r.PersonType = webhook.PersonType(strconv.Itoa(t.Int()))
+		}
+	}
+
+	return nil
+}
+
+// Time returns the check-in time.
+func (r *CheckinRecord) Time() time.Time {
+	return time.Unix(0, r.CheckinTime*int64(time.Millisecond))
+}
+
+// Data converts the record to a check-in webhook event, so historical and
+// live events can be processed by the same code.
+func (r *CheckinRecord) Data() *webhook.Data {
+	person, device, place := r.PersonData, r.DeviceData, r.PlaceData
+
+	return &webhook.Data{
+		DataType: webhook.DataCheckinPicture,
+		EventData: &webhook.EventData{
+			ActionType: webhook.ActionAdd,
+			Date:       r.Date,
+			Time:       uint64(r.CheckinTime),
+		},
+		PersonData: &person,
+		DeviceData: &device,
+		PlaceData:  &place,
+	}
+}
+
+// Timestamp returns t in milliseconds, the unit used by the check-in APIs.
+func Timestamp(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+type CheckinByPlaceRequest struct {
+	PlaceID int `url:"placeID"`
+
+	// From and To are timestamps in milliseconds, see Timestamp.
+	From int64 `url:"from"`
+	To   int64 `url:"to"`
+
+	Devices []string `url:"devices,comma,omitempty"`
+	Type    string   `url:"type,omitempty"`
+	Page    int      `url:"page,omitempty"`
+	Size    int      `url:"size,omitempty"`
+}
+
+// CheckinsByPlace lists the check-ins of a place in a timestamp range.
+func (s *PersonService) CheckinsByPlace(ctx context.Context, data CheckinByPlaceRequest) ([]CheckinRecord, error) {
+	req, err := s.client.NewRequest("person/getCheckinByPlaceIdInTimestamp", urlencodeBody(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var a []CheckinRecord
+	_, err = s.client.Do(ctx, req, &a)
+	return a, err
+}
+
+type CheckinByAliasIDRequest struct {
+	PlaceID int    `url:"placeID"`
+	AliasID string `url:"aliasID"`
+
+	// From and To are timestamps in milliseconds, see Timestamp.
+	From int64 `url:"from"`
+	To   int64 `url:"to"`
+
+	Page int `url:"page,omitempty"`
+	Size int `url:"size,omitempty"`
+}
+
+// CheckinsByAliasID lists the check-ins of a person at a place in a
+// timestamp range.
+func (s *PersonService) CheckinsByAliasID(ctx context.Context, data CheckinByAliasIDRequest) ([]CheckinRecord, error) {
+	req, err := s.client.NewRequest("person/getCheckinByPersonByPlaceIdInTimestamp", urlencodeBody(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var a []CheckinRecord
+	_, err = s.client.Do(ctx, req, &a)
+	return a, err
+}
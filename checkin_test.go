@@ -0,0 +1,170 @@
+package hanetai
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"giautm.dev/hanetai/hanettest"
+	"giautm.dev/hanetai/webhook"
+)
+
+func addTestCheckins(srv *hanettest.Server, base time.Time) {
+	srv.AddCheckin(hanettest.Checkin{
+		PersonID:   "1858497629510868990",
+		AliasID:    "VCFL1231231",
+		PersonName: "Nguyễn Văn A",
+		Title:      "Nhân viên",
+		Avatar:     "https://example.com/a.jpg",
+		PlaceID:    1542,
+		DeviceID:   "C21024B155",
+		DeviceName: "Front door",
+		Time:       base,
+	})
+	srv.AddCheckin(hanettest.Checkin{
+		PersonID:   "1858497629510868992",
+		AliasID:    "852576",
+		PersonName: "Trần Thị B",
+		Title:      "Nhân viên",
+		PlaceID:    1542,
+		DeviceID:   "C21024B155",
+		DeviceName: "Front door",
+		Time:       base.Add(time.Hour),
+	})
+	srv.AddCheckin(hanettest.Checkin{
+		PersonID:   "1858497629510868990",
+		AliasID:    "VCFL1231231",
+		PersonName: "Nguyễn Văn A",
+		Title:      "Nhân viên",
+		PlaceID:    1542,
+		DeviceID:   "C21024B155",
+		DeviceName: "Front door",
+		Time:       base.Add(48 * time.Hour),
+	})
+}
+
+func TestPersonService_CheckinsByPlace(t *testing.T) {
+	c, srv := newTestClient(t)
+	base := time.Date(2021, 7, 16, 8, 0, 0, 0, time.UTC)
+	addTestCheckins(srv, base)
+
+	type args struct {
+		ctx  context.Context
+		data CheckinByPlaceRequest
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantAliases []string
+		wantErr     bool
+	}{
+		{
+			name: "happy case",
+			args: args{
+				ctx: context.Background(),
+				data: CheckinByPlaceRequest{
+					PlaceID: 1542,
+					From:    Timestamp(base),
+					To:      Timestamp(base.Add(24 * time.Hour)),
+				},
+			},
+			wantAliases: []string{"VCFL1231231", "852576"},
+		},
+		{
+			name: "second page",
+			args: args{
+				ctx: context.Background(),
+				data: CheckinByPlaceRequest{
+					PlaceID: 1542,
+					From:    Timestamp(base),
+					To:      Timestamp(base.Add(72 * time.Hour)),
+					Page:    2,
+					Size:    2,
+				},
+			},
+			wantAliases: []string{"VCFL1231231"},
+		},
+		{
+			name: "unknown place",
+			args: args{
+				ctx: context.Background(),
+				data: CheckinByPlaceRequest{
+					PlaceID: 1,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Persons.CheckinsByPlace(tt.args.ctx, tt.args.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PersonService.CheckinsByPlace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var aliases []string
+			for _, r := range got {
+				aliases = append(aliases, r.AliasID)
+			}
+			if !reflect.DeepEqual(aliases, tt.wantAliases) {
+				t.Errorf("PersonService.CheckinsByPlace() = %v, want %v", aliases, tt.wantAliases)
+			}
+		})
+	}
+}
+
+func TestPersonService_CheckinsByAliasID(t *testing.T) {
+	c, srv := newTestClient(t)
+	base := time.Date(2021, 7, 16, 8, 0, 0, 0, time.UTC)
+	addTestCheckins(srv, base)
+
+	got, err := c.Persons.CheckinsByAliasID(context.Background(), CheckinByAliasIDRequest{
+		PlaceID: 1542,
+		AliasID: "VCFL1231231",
+		From:    Timestamp(base),
+		To:      Timestamp(base.Add(72 * time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("PersonService.CheckinsByAliasID() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("PersonService.CheckinsByAliasID() = %v records, want %v", len(got), 2)
+	}
+
+	r := got[0]
+	if !r.Time().Equal(base) {
+		t.Errorf("CheckinRecord.Time() = %v, want %v", r.Time(), base)
+	}
+	if r.DetectedImageURL != "https://example.com/a.jpg" {
+		t.Errorf("CheckinRecord.DetectedImageURL = %v, want avatar", r.DetectedImageURL)
+	}
+
+	want := &webhook.Data{
+		DataType: webhook.DataCheckinPicture,
+		EventData: &webhook.EventData{
+			ActionType: webhook.ActionAdd,
+			Date:       "2021-07-16 08:00:00",
+			Time:       uint64(Timestamp(base)),
+		},
+		PersonData: &webhook.PersonData{
+			DetectedImageURL: "https://example.com/a.jpg",
+			PersonID:         "1858497629510868990",
+			AliasID:          "VCFL1231231",
+			PersonName:       "Nguyễn Văn A",
+			PersonType:       webhook.PersonEmployee,
+		},
+		DeviceData: &webhook.DeviceData{
+			DeviceID:   "C21024B155",
+			DeviceName: "Front door",
+		},
+		PlaceData: &webhook.PlaceData{
+			PlaceID:   1542,
+			PlaceName: "Hanet HQ",
+		},
+	}
+	if got := r.Data(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckinRecord.Data() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"giautm.dev/hanetai"
+)
+
+const defaultLoginAddr = "127.0.0.1:8085"
+
+type AuthLoginCmd struct {
+	ClientID     string        `kong:"required,name='client-id',env='HANET_CLIENT_ID',help:'The OAuth2 client ID'"`
+	ClientSecret string        `kong:"required,name='client-secret',env='HANET_CLIENT_SECRET',help:'The OAuth2 client secret'"`
+	Addr         string        `kong:"optional,name='addr',help:'The loopback address receiving the callback'"`
+	RedirectURL  string        `kong:"optional,name='redirect-url',help:'The registered redirect URL, defaults to http://<addr>/callback'"`
+	Timeout      time.Duration `kong:"optional,name='timeout',help:'How long to wait for the authorization'"`
+	AuthURL      string        `kong:"optional,name='auth-url',help:'The OAuth2 authorization URL, defaults to the one of Hanet'"`
+	TokenURL     string        `kong:"optional,name='token-url',help:'The OAuth2 token URL, defaults to the one of Hanet'"`
+}
+
+func (r *AuthLoginCmd) Run(ctx *CliContext) error {
+	addr := r.Addr
+	if addr == "" {
+		addr = defaultLoginAddr
+	}
+	redirectURL := r.RedirectURL
+	if redirectURL == "" {
+		redirectURL = "http://" + addr + "/callback"
+	}
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return err
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	f := &tokenFile{
+		ClientID:     r.ClientID,
+		ClientSecret: r.ClientSecret,
+		RedirectURL:  redirectURL,
+		AuthURL:      r.AuthURL,
+		TokenURL:     r.TokenURL,
+	}
+	config := f.config()
+
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	type result struct {
+		code string
+		err  error
+	}
+	done := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(u.Path, func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		var res result
+		switch {
+		case q.Get("state") != state:
+			res.err = errors.New("invalid OAuth2 state")
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s", q.Get("error"))
+		case q.Get("code") == "":
+			res.err = errors.New("missing authorization code")
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in, you can close this window.")
+		}
+		select {
+		case done <- res:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	fmt.Fprintf(os.Stderr, "Open this URL in your browser to log in:\n\n  %s\n\n", config.AuthCodeURL(state))
+
+	wait, cancel := context.WithTimeout(ctx.Context, timeout)
+	defer cancel()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-wait.Done():
+		return wait.Err()
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	f.Token, err = config.Exchange(ctx.Context, res.code)
+	if err != nil {
+		return err
+	}
+	if err := f.save(); err != nil {
+		return err
+	}
+
+	path, _ := tokenPath()
+	fmt.Printf("Successfully logged in, token saved to %s\n", path)
+	return nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type AuthStatusCmd struct{}
+
+type authStatus struct {
+	Path            string    `json:"path"`
+	ClientID        string    `json:"clientID"`
+	Expiry          time.Time `json:"expiry"`
+	HasRefreshToken bool      `json:"hasRefreshToken"`
+	Valid           bool      `json:"valid"`
+	Email           string    `json:"email,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+func (l *AuthStatusCmd) Run(ctx *CliContext) error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+	f, err := loadTokenFile()
+	if err != nil {
+		return err
+	}
+
+	status := authStatus{
+		Path:            path,
+		ClientID:        f.ClientID,
+		Expiry:          f.Token.Expiry,
+		HasRefreshToken: f.Token.RefreshToken != "",
+	}
+
+	// Calling the API refreshes the token if needed.
+	c := ctx.withLogger(hanetai.NewClient(nil, &persistentTokenSource{ctx: ctx.Context}))
+	profile, err := c.Profile.Me(ctx.Context)
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Valid = true
+		status.Email = profile.Email
+		if f, err := loadTokenFile(); err == nil {
+			status.Expiry = f.Token.Expiry
+		}
+	}
+
+	if ctx.JSON {
+		return json.NewEncoder(ctx.Writer()).Encode(status)
+	}
+
+	w := ctx.Writer()
+	fmt.Fprintf(w, "Token file:    %s\n", status.Path)
+	fmt.Fprintf(w, "Client ID:     %s\n", status.ClientID)
+	if status.Expiry.IsZero() {
+		fmt.Fprintf(w, "Expiry:        never\n")
+	} else {
+		fmt.Fprintf(w, "Expiry:        %s\n", status.Expiry.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Refresh token: %t\n", status.HasRefreshToken)
+	if status.Valid {
+		fmt.Fprintf(w, "Logged in as:  %s\n", status.Email)
+	} else {
+		fmt.Fprintf(w, "Error:         %s\n", status.Error)
+	}
+	return nil
+}
+
+type AuthLogoutCmd struct{}
+
+func (l *AuthLogoutCmd) Run(ctx *CliContext) error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return errNotLoggedIn
+	}
+	if err == nil {
+		fmt.Println("Successfully logged out")
+	}
+	return err
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"time"
@@ -20,13 +21,30 @@ type CliContext struct {
 }
 
 func (c *CliContext) NewClient() *hanetai.Client {
-	source := oauth2.StaticTokenSource(&oauth2.Token{
-		AccessToken: c.AccessToken,
-		TokenType:   "Bearer",
-	})
-	return hanetai.NewClient(&http.Client{
+	return c.withLogger(hanetai.NewClient(&http.Client{
 		Timeout: 60 * time.Second,
-	}, source)
+	}, c.TokenSource()))
+}
+
+// withLogger logs the requests of client to stderr with --debug.
+func (c *CliContext) withLogger(client *hanetai.Client) *hanetai.Client {
+	if c.Debug {
+		client.Logger = hanetai.StdLogger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+	return client
+}
+
+// TokenSource returns the access token given by flag or environment, or the
+// token saved by `hanet auth login`, refreshed and saved back as needed.
+func (c *CliContext) TokenSource() oauth2.TokenSource {
+	if c.AccessToken != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{
+			AccessToken: c.AccessToken,
+			TokenType:   "Bearer",
+		})
+	}
+
+	return &persistentTokenSource{ctx: c.Context}
 }
 
 func (c *CliContext) Writer() io.Writer {
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"giautm.dev/hanetai"
+)
+
+type DepartmentLsCmd struct {
+	PlaceID int `kong:"required,name='place-id',help:'The place to list departments'"`
+}
+
+func (l *DepartmentLsCmd) Run(ctx *CliContext) error {
+	c := ctx.NewClient()
+	items, err := c.Departments.List(ctx.Context, hanetai.DepartmentListRequest{
+		PlaceID: l.PlaceID,
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.JSON {
+		return json.NewEncoder(ctx.Writer()).Encode(items)
+	}
+
+	s := csv.NewWriter(ctx.Writer())
+	defer s.Flush()
+
+	if !ctx.NoHeader {
+		err = s.Write([]string{
+			"ID",
+			"PlaceID",
+			"Name",
+			"Desc",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, i := range items {
+		err = s.Write([]string{
+			strconv.Itoa(i.ID),
+			strconv.Itoa(i.PlaceID),
+			i.Name,
+			i.Desc,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type DepartmentAddCmd struct {
+	PlaceID int    `kong:"required,name='place-id',help:'The place that department belong to'"`
+	Name    string `kong:"required,name='name',help:'The name of department'"`
+	Desc    string `kong:"optional,name='desc',help:'The description of department'"`
+}
+
+func (r *DepartmentAddCmd) Run(ctx *CliContext) error {
+	c := ctx.NewClient()
+	department, err := c.Departments.Add(ctx.Context, hanetai.Department{
+		PlaceID: r.PlaceID,
+		Name:    r.Name,
+		Desc:    r.Desc,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.JSON {
+		return json.NewEncoder(ctx.Writer()).Encode(department)
+	}
+	fmt.Printf("Successfully added %d\n", department.ID)
+	return nil
+}
+
+type DepartmentUpdateCmd struct {
+	ID        int    `kong:"required,name='id',help:'The ID of department will update'"`
+	Name      string `kong:"optional,name='name',help:'The name of department'"`
+	Desc      string `kong:"optional,name='desc',help:'The description of department'"`
+	ClearDesc bool   `kong:"optional,name='clear-desc',help:'Remove the description of department'"`
+}
+
+func (r *DepartmentUpdateCmd) Run(ctx *CliContext) error {
+	data := hanetai.DepartmentUpdateRequest{ID: r.ID}
+	if r.Name != "" {
+		data.Name = &r.Name
+	}
+	if r.Desc != "" || r.ClearDesc {
+		data.Desc = &r.Desc
+	}
+
+	c := ctx.NewClient()
+	err := c.Departments.Update(ctx.Context, data)
+	if err == nil {
+		fmt.Printf("Successfully updated %d\n", r.ID)
+	}
+	return err
+}
+
+type DepartmentRmCmd struct {
+	ID int `kong:"required,name='id',help:'The ID of department will delete'"`
+}
+
+func (r *DepartmentRmCmd) Run(ctx *CliContext) error {
+	c := ctx.NewClient()
+	err := c.Departments.Remove(ctx.Context, hanetai.DepartmentRemoveRequest{
+		ID: r.ID,
+	})
+	if err == nil {
+		fmt.Printf("Successfully removed %d\n", r.ID)
+	}
+	return err
+}
+
+type DepartmentAssignCmd struct {
+	ID       int      `kong:"required,name='id',help:'The ID of department'"`
+	PlaceID  int      `kong:"required,name='place-id',help:'The place that persons belong to'"`
+	AliasIDs []string `kong:"required,name='alias-ids',help:'The alias ID of persons will assign'"`
+}
+
+func (r *DepartmentAssignCmd) Run(ctx *CliContext) error {
+	c := ctx.NewClient()
+	err := c.Departments.AddPersons(ctx.Context, hanetai.DepartmentPersonsRequest{
+		ID:       r.ID,
+		PlaceID:  r.PlaceID,
+		AliasIDs: r.AliasIDs,
+	})
+	if err == nil {
+		fmt.Printf("Successfully assigned %d persons\n", len(r.AliasIDs))
+	}
+	return err
+}
+
+type DepartmentUnassignCmd struct {
+	ID       int      `kong:"required,name='id',help:'The ID of department'"`
+	PlaceID  int      `kong:"required,name='place-id',help:'The place that persons belong to'"`
+	AliasIDs []string `kong:"required,name='alias-ids',help:'The alias ID of persons will unassign'"`
+}
+
+func (r *DepartmentUnassignCmd) Run(ctx *CliContext) error {
+	c := ctx.NewClient()
+	err := c.Departments.RemovePersons(ctx.Context, hanetai.DepartmentPersonsRequest{
+		ID:       r.ID,
+		PlaceID:  r.PlaceID,
+		AliasIDs: r.AliasIDs,
+	})
+	if err == nil {
+		fmt.Printf("Successfully unassigned %d persons\n", len(r.AliasIDs))
+	}
+	return err
+}
+
+type DepartmentLsPersonCmd struct {
+	ID      int `kong:"required,name='id',help:'The ID of department'"`
+	PlaceID int `kong:"required,name='place-id',help:'The place that department belong to'"`
+}
+
+func (l *DepartmentLsPersonCmd) Run(ctx *CliContext) error {
+	c := ctx.NewClient()
+	items, err := c.Departments.ListPersons(ctx.Context, hanetai.DepartmentListPersonRequest{
+		ID:      l.ID,
+		PlaceID: l.PlaceID,
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.JSON {
+		return json.NewEncoder(ctx.Writer()).Encode(items)
+	}
+
+	s := csv.NewWriter(ctx.Writer())
+	defer s.Flush()
+
+	if !ctx.NoHeader {
+		err = s.Write([]string{
+			"PersonID",
+			"AliasID",
+			"Title",
+			"Name",
+			"Avatar",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, i := range items {
+		err = s.Write([]string{
+			i.PersonID,
+			i.AliasID,
+			i.Title,
+			i.Name,
+			i.Avatar,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
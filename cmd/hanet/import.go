@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"giautm.dev/hanetai/roster"
+)
+
+type PersonImportCmd struct {
+	File        string `kong:"required,name='file',help:'The manifest file, CSV or JSONL (aliasID,name,title,type,photo)'"`
+	PlaceID     int    `kong:"required,name='place-id',help:'The place to register persons'"`
+	Results     string `kong:"optional,name='results',help:'The result file, defaults to the manifest file with .results.jsonl'"`
+	Concurrency int    `kong:"optional,name='concurrency',help:'Number of concurrent registrations'"`
+}
+
+func (r *PersonImportCmd) Run(ctx *CliContext) error {
+	persons, err := roster.ReadFile(r.File)
+	if err != nil {
+		return err
+	}
+
+	results := r.Results
+	if results == "" {
+		results = r.File + ".results.jsonl"
+	}
+
+	// Rows that already succeeded in a previous run are skipped.
+	var done map[string]bool
+	if f, err := os.Open(results); err == nil {
+		done, err = roster.ReadImportResults(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(results, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var ok, exists, failed int
+	enc := json.NewEncoder(f)
+	imp := &roster.Importer{
+		Persons:     ctx.NewClient().Persons,
+		Concurrency: r.Concurrency,
+	}
+	err = imp.Import(ctx.Context, r.PlaceID, persons, done, func(res roster.ImportResult) error {
+		switch res.Status {
+		case roster.StatusOK:
+			ok++
+		case roster.StatusExists:
+			exists++
+		default:
+			failed++
+		}
+		if ctx.JSON {
+			if err := json.NewEncoder(ctx.Writer()).Encode(res); err != nil {
+				return err
+			}
+		}
+		return enc.Encode(res)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !ctx.JSON {
+		fmt.Printf("Imported %d, existing %d, failed %d, skipped %d, see %s\n", ok, exists, failed, len(persons)-ok-exists-failed, results)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d rows failed", failed)
+	}
+	return nil
+}
@@ -7,16 +7,33 @@ import (
 )
 
 var cli struct {
-	AccessToken string `kong:"required,env='HANET_ACCESS_TOKEN'"`
+	AccessToken string `kong:"optional,env='HANET_ACCESS_TOKEN'"`
+	Debug       bool   `kong:"optional,name='debug',help:'Log the API requests to stderr'"`
 	JSON        bool   `kong:"optional,name='json',default:false"`
 	NoHeader    bool   `kong:"optional,name='no-header',default:false"`
-	Person      struct {
+	Auth        struct {
+		Login  AuthLoginCmd  `cmd:"" help:"Log in with OAuth2 and save the token."`
+		Status AuthStatusCmd `cmd:"" help:"Show the saved token."`
+		Logout AuthLogoutCmd `cmd:"" help:"Remove the saved token."`
+	} `cmd:""`
+	Person struct {
 		Register        PersonRegisterCmd  `cmd:"" help:"Register person at the place."`
+		Import          PersonImportCmd    `cmd:"" help:"Register persons at the place from a CSV or JSONL manifest."`
 		Ls              PersonLsCmd        `cmd:"" help:"List person at the place."`
 		LsByAlias       LsByAliasCmd       `cmd:"" help:"List person at the place."`
 		UserInfoByAlias UserInfoByAliasCmd `cmd:"" help:"Get User Info by Alias ID."`
 		Rm              PersonRmCmd        `cmd:"" help:"Remove a person using their ID."`
 		RmByPlaceAlias  PersonRmByAliasCmd `cmd:"" help:"Remove a person from the place"`
+		Sync            PersonSyncCmd      `cmd:"" help:"Sync persons of the place with a roster file."`
+	} `cmd:""`
+	Department struct {
+		Ls       DepartmentLsCmd       `cmd:"" help:"List departments at the place."`
+		Add      DepartmentAddCmd      `cmd:"" help:"Add a department to the place."`
+		Update   DepartmentUpdateCmd   `cmd:"" help:"Update a department."`
+		Rm       DepartmentRmCmd       `cmd:"" help:"Remove a department."`
+		Assign   DepartmentAssignCmd   `cmd:"" help:"Assign persons to the department."`
+		Unassign DepartmentUnassignCmd `cmd:"" help:"Remove persons from the department."`
+		LsPerson DepartmentLsPersonCmd `cmd:"" help:"List persons of the department."`
 	} `cmd:""`
 	Device struct {
 		Ls     DeviceLsCmd               `cmd:"" help:"List device at the place."`
@@ -25,6 +42,13 @@ var cli struct {
 	Profile struct {
 		Me ProfileMeCmd `cmd:"" help:"Get profile of current user."`
 	} `cmd:""`
+	Report struct {
+		Attendance ReportAttendanceCmd `cmd:"" help:"Report the attendance of the persons of the place per day."`
+	} `cmd:""`
+	Webhook struct {
+		Send  WebhookSendCmd  `cmd:"" help:"Send signed test events to a webhook receiver."`
+		Serve WebhookServeCmd `cmd:"" help:"Receive and print the webhook events."`
+	} `cmd:""`
 }
 
 func main() {
@@ -32,7 +56,7 @@ func main() {
 	err := ctx.Run(&CliContext{
 		AccessToken: cli.AccessToken,
 		Context:     context.Background(),
-		Debug:       false,
+		Debug:       cli.Debug,
 		JSON:        cli.JSON,
 		NoHeader:    cli.NoHeader,
 	})
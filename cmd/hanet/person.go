@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 
@@ -47,16 +48,22 @@ type PersonLsCmd struct {
 	PersonType string `kong:"optional,name='type',help:'The person type'"`
 	Page       int    `kong:"optional,name='page',help:'The page number'"`
 	Size       int    `kong:"optional,name='size',help:'Number of items per page'"`
+	All        bool   `kong:"optional,name='all',help:'Stream every person of the place, page by page'"`
 }
 
 func (l *PersonLsCmd) Run(ctx *CliContext) error {
 	c := ctx.NewClient()
-	items, err := c.Persons.ListByPlace(ctx.Context, hanetai.PersonListByPlaceRequest{
+	data := hanetai.PersonListByPlaceRequest{
 		PlaceID: l.PlaceID,
 		Type:    l.PersonType,
 		Page:    l.Page,
 		Size:    l.Size,
-	})
+	}
+	if l.All {
+		return l.streamAll(ctx, c.Persons.ListByPlaceAll(ctx.Context, data))
+	}
+
+	items, err := c.Persons.ListByPlace(ctx.Context, data)
 	if err != nil {
 		return err
 	}
@@ -68,25 +75,13 @@ func (l *PersonLsCmd) Run(ctx *CliContext) error {
 	defer s.Flush()
 
 	if !ctx.NoHeader {
-		err = s.Write([]string{
-			"PersonID",
-			"AliasID",
-			"Title",
-			"Name",
-			"Avatar",
-		})
+		err = s.Write(personListHeader)
 		if err != nil {
 			return err
 		}
 	}
 	for _, i := range items {
-		err = s.Write([]string{
-			i.PersonID,
-			i.AliasID,
-			i.Title,
-			i.Name,
-			i.Avatar,
-		})
+		err = s.Write(personListRecord(i))
 		if err != nil {
 			return err
 		}
@@ -94,6 +89,69 @@ func (l *PersonLsCmd) Run(ctx *CliContext) error {
 	return nil
 }
 
+// streamAll writes the persons while they are fetched, the JSON output is
+// a single array like the paged listing.
+func (l *PersonLsCmd) streamAll(ctx *CliContext, it *hanetai.PersonIterator) error {
+	w := ctx.Writer()
+	if ctx.JSON {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		for n := 0; it.Next(); n++ {
+			if n > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			b, err := json.Marshal(it.Person())
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "]\n")
+		return err
+	}
+
+	s := csv.NewWriter(w)
+	defer s.Flush()
+
+	if !ctx.NoHeader {
+		if err := s.Write(personListHeader); err != nil {
+			return err
+		}
+	}
+	for it.Next() {
+		if err := s.Write(personListRecord(it.Person())); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+var personListHeader = []string{
+	"PersonID",
+	"AliasID",
+	"Title",
+	"Name",
+	"Avatar",
+}
+
+func personListRecord(i hanetai.PersonListItem) []string {
+	return []string{
+		i.PersonID,
+		i.AliasID,
+		i.Title,
+		i.Name,
+		i.Avatar,
+	}
+}
+
 type PersonRegisterCmd struct {
 	PlaceID int      `kong:"required,name='place-id',help:'The place that person belong to'"`
 	AliasID string   `kong:"required,name='alias-id',help:'The alias ID of person will register'"`
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"giautm.dev/hanetai"
+	"giautm.dev/hanetai/attendance"
+	"giautm.dev/hanetai/webhook"
+)
+
+// reportPageSize is the number of check-ins read per request.
+const reportPageSize = 500
+
+type ReportAttendanceCmd struct {
+	PlaceID   int           `kong:"required,name='place-id',help:'The place of the check-ins'"`
+	From      string        `kong:"required,name='from',help:'The first day of the report, as 2006-01-02'"`
+	To        string        `kong:"required,name='to',help:'The last day of the report, as 2006-01-02'"`
+	Policy    string        `kong:"optional,name='policy',help:'The shift policies, a YAML or JSON file, to classify the days and report the absences'"`
+	Timezone  string        `kong:"optional,name='timezone',help:'The time zone of the days, defaults to Asia/Ho_Chi_Minh'"`
+	DayStart  time.Duration `kong:"optional,name='day-start',help:'Count the check-ins before this time of the day for the day before, e.g. 4h'"`
+	Overnight time.Duration `kong:"optional,name='overnight',help:'Keep a check-in in the day of the previous one when it is at most this long after it, so a night shift is a single day; defaults to 10h when a policy ends the next day'"`
+	Summary   bool          `kong:"optional,name='summary',help:'Write the summary per person instead of the days, for CSV'"`
+	Output    string        `kong:"optional,name='output',help:'Write the report to a file, as XLSX with a Summary and a Detail sheet when it ends with .xlsx'"`
+}
+
+type attendanceDay struct {
+	AliasID         string   `json:"aliasID"`
+	PersonName      string   `json:"personName"`
+	Date            string   `json:"date"`
+	Policy          string   `json:"policy,omitempty"`
+	FirstIn         string   `json:"firstIn,omitempty"`
+	LastOut         string   `json:"lastOut,omitempty"`
+	Checkins        int      `json:"checkins"`
+	Statuses        []string `json:"statuses,omitempty"`
+	LateMinutes     int      `json:"lateMinutes"`
+	EarlyMinutes    int      `json:"earlyMinutes"`
+	OvertimeMinutes int      `json:"overtimeMinutes"`
+	WorkedMinutes   int      `json:"workedMinutes"`
+}
+
+type attendanceSummary struct {
+	AliasID         string `json:"aliasID"`
+	PersonName      string `json:"personName"`
+	Policy          string `json:"policy,omitempty"`
+	Days            int    `json:"days"`
+	Present         int    `json:"present"`
+	Absent          int    `json:"absent"`
+	Late            int    `json:"late"`
+	LeftEarly       int    `json:"leftEarly"`
+	Overtime        int    `json:"overtime"`
+	LateMinutes     int    `json:"lateMinutes"`
+	EarlyMinutes    int    `json:"earlyMinutes"`
+	OvertimeMinutes int    `json:"overtimeMinutes"`
+	WorkedMinutes   int    `json:"workedMinutes"`
+}
+
+type attendanceReport struct {
+	Summary []attendanceSummary `json:"summary"`
+	Days    []attendanceDay     `json:"days"`
+}
+
+func (r *ReportAttendanceCmd) Run(ctx *CliContext) error {
+	loc := webhook.DateLocation
+	if r.Timezone != "" {
+		l, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", r.From, loc)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to, err := time.ParseInLocation("2006-01-02", r.To, loc)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--to is before --from")
+	}
+
+	e := &attendance.Evaluator{Location: loc}
+	if r.Policy != "" {
+		if e.Config, err = attendance.LoadConfig(r.Policy); err != nil {
+			return err
+		}
+	}
+
+	ts := attendance.NewTimesheet(loc)
+	ts.DayStart = r.DayStart
+	ts.Overnight = r.Overnight
+	if ts.Overnight == 0 && e.Config != nil && e.Config.EndsNextDay() {
+		ts.Overnight = 10 * time.Hour
+	}
+
+	// A day may continue on the next one, read the days around the report
+	// so the first and last days are complete.
+	first, last := from, to
+	if ts.DayStart > 0 || ts.Overnight > 0 {
+		first, last = from.AddDate(0, 0, -1), to.AddDate(0, 0, 1)
+	}
+
+	c := ctx.NewClient()
+	// Read a day at a time, the check-in APIs limit the range of a request.
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		for page := 1; ; page++ {
+			records, err := c.Persons.CheckinsByPlace(ctx.Context, hanetai.CheckinByPlaceRequest{
+				PlaceID: r.PlaceID,
+				From:    hanetai.Timestamp(day),
+				To:      hanetai.Timestamp(next) - 1,
+				Type:    string(webhook.PersonEmployee),
+				Page:    page,
+				Size:    reportPageSize,
+			})
+			if err != nil {
+				return err
+			}
+			for _, rec := range records {
+				ts.AddData(rec.Data())
+			}
+			if len(records) < reportPageSize {
+				break
+			}
+		}
+	}
+
+	// With policies, the employees without check-ins are reported absent.
+	var employees []attendance.Employee
+	if e.Config != nil {
+		it := c.Persons.ListByPlaceAll(ctx.Context, hanetai.PersonListByPlaceRequest{
+			PlaceID: r.PlaceID,
+			Type:    string(webhook.PersonEmployee),
+		})
+		for it.Next() {
+			p := it.Person()
+			employees = append(employees, attendance.Employee{
+				AliasID: p.AliasID,
+				Name:    p.Name,
+				PlaceID: r.PlaceID,
+			})
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+
+	results := e.Evaluate(ts.Days(), employees, attendance.DateOf(from), attendance.DateOf(to))
+	report := newAttendanceReport(results, loc)
+
+	if r.Output == "" {
+		return r.write(ctx, ctx.Writer(), report)
+	}
+
+	f, err := os.Create(r.Output)
+	if err != nil {
+		return err
+	}
+	err = r.write(ctx, f, report)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// write writes the report as XLSX when the output ends with .xlsx, as JSON
+// or as CSV.
+func (r *ReportAttendanceCmd) write(ctx *CliContext, w io.Writer, report *attendanceReport) error {
+	if strings.EqualFold(filepath.Ext(r.Output), ".xlsx") {
+		return writeXLSX(w, report.sheets(!ctx.NoHeader))
+	}
+
+	if ctx.JSON {
+		return json.NewEncoder(w).Encode(report)
+	}
+
+	s := csv.NewWriter(w)
+	header, rows := report.daysTable()
+	if r.Summary {
+		header, rows = report.summaryTable()
+	}
+	if !ctx.NoHeader {
+		if err := s.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = fmt.Sprint(v)
+		}
+		if err := s.Write(record); err != nil {
+			return err
+		}
+	}
+	s.Flush()
+	return s.Error()
+}
+
+func newAttendanceReport(results []attendance.Result, loc *time.Location) *attendanceReport {
+	report := &attendanceReport{
+		Summary: []attendanceSummary{},
+		Days:    []attendanceDay{},
+	}
+	for _, s := range attendance.Summarize(results) {
+		report.Summary = append(report.Summary, attendanceSummary(s))
+	}
+	for _, res := range results {
+		d := attendanceDay{
+			AliasID:         res.AliasID,
+			PersonName:      res.PersonName,
+			Date:            res.Date.String(),
+			Policy:          res.Policy,
+			LateMinutes:     res.LateMinutes,
+			EarlyMinutes:    res.EarlyMinutes,
+			OvertimeMinutes: res.OvertimeMinutes,
+			WorkedMinutes:   res.WorkedMinutes,
+		}
+		for _, s := range res.Statuses {
+			d.Statuses = append(d.Statuses, string(s))
+		}
+		if day := res.Day; day != nil {
+			d.FirstIn = day.FirstIn.In(loc).Format(webhook.DateLayout)
+			d.LastOut = day.LastOut.In(loc).Format(webhook.DateLayout)
+			d.Checkins = len(day.Checkins)
+		}
+		report.Days = append(report.Days, d)
+	}
+	return report
+}
+
+func (r *attendanceReport) summaryTable() ([]string, [][]interface{}) {
+	header := []string{
+		"AliasID",
+		"Name",
+		"Policy",
+		"Days",
+		"Present",
+		"Absent",
+		"Late",
+		"LeftEarly",
+		"Overtime",
+		"LateMinutes",
+		"EarlyMinutes",
+		"OvertimeMinutes",
+		"WorkedMinutes",
+	}
+	var rows [][]interface{}
+	for _, s := range r.Summary {
+		rows = append(rows, []interface{}{
+			s.AliasID,
+			s.PersonName,
+			s.Policy,
+			s.Days,
+			s.Present,
+			s.Absent,
+			s.Late,
+			s.LeftEarly,
+			s.Overtime,
+			s.LateMinutes,
+			s.EarlyMinutes,
+			s.OvertimeMinutes,
+			s.WorkedMinutes,
+		})
+	}
+	return header, rows
+}
+
+func (r *attendanceReport) daysTable() ([]string, [][]interface{}) {
+	header := []string{
+		"AliasID",
+		"Name",
+		"Date",
+		"Policy",
+		"FirstIn",
+		"LastOut",
+		"Checkins",
+		"Status",
+		"LateMinutes",
+		"EarlyMinutes",
+		"OvertimeMinutes",
+		"WorkedMinutes",
+	}
+	var rows [][]interface{}
+	for _, d := range r.Days {
+		rows = append(rows, []interface{}{
+			d.AliasID,
+			d.PersonName,
+			d.Date,
+			d.Policy,
+			d.FirstIn,
+			d.LastOut,
+			d.Checkins,
+			strings.Join(d.Statuses, " "),
+			d.LateMinutes,
+			d.EarlyMinutes,
+			d.OvertimeMinutes,
+			d.WorkedMinutes,
+		})
+	}
+	return header, rows
+}
+
+func (r *attendanceReport) sheets(header bool) []xlsxSheet {
+	sheet := func(name string, h []string, rows [][]interface{}) xlsxSheet {
+		s := xlsxSheet{Name: name}
+		if header {
+			row := make([]interface{}, len(h))
+			for i, v := range h {
+				row[i] = v
+			}
+			s.Rows = append(s.Rows, row)
+		}
+		s.Rows = append(s.Rows, rows...)
+		return s
+	}
+
+	h, rows := r.summaryTable()
+	summary := sheet("Summary", h, rows)
+	h, rows = r.daysTable()
+	return []xlsxSheet{summary, sheet("Detail", h, rows)}
+}
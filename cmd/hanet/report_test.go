@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"giautm.dev/hanetai/attendance"
+	"giautm.dev/hanetai/webhook"
+)
+
+func testAttendanceReport(t *testing.T) *attendanceReport {
+	t.Helper()
+
+	c, err := attendance.ParseConfig([]byte(`
+policies:
+  night:
+    start: "22:00"
+    end: "06:00"
+default: night
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc := webhook.DateLocation
+	at := func(day, hour int) time.Time {
+		return time.Date(2021, time.July, day, hour, 0, 0, 0, loc)
+	}
+	ts := &attendance.Timesheet{Location: loc, Overnight: 10 * time.Hour}
+	for _, tm := range []time.Time{at(26, 22), at(27, 6)} {
+		ts.Add(attendance.Checkin{AliasID: "A", PersonName: "Nguyễn <A>", Time: tm})
+	}
+
+	e := &attendance.Evaluator{Config: c, Location: loc}
+	results := e.Evaluate(ts.Days(), nil, attendance.DateOf(at(26, 0)), attendance.DateOf(at(27, 0)))
+	return newAttendanceReport(results, loc)
+}
+
+func TestNewAttendanceReport(t *testing.T) {
+	r := testAttendanceReport(t)
+
+	if len(r.Days) != 2 {
+		t.Fatalf("Days = %+v, want 2 days", r.Days)
+	}
+	if d := r.Days[0]; d.FirstIn != "2021-07-26 22:00:00" || d.LastOut != "2021-07-27 06:00:00" || d.WorkedMinutes != 480 {
+		t.Errorf("Days[0] = %+v, want the night shift", d)
+	}
+	if d := r.Days[1]; len(d.Statuses) != 1 || d.Statuses[0] != string(attendance.StatusAbsent) {
+		t.Errorf("Days[1] = %+v, want absent", d)
+	}
+	if len(r.Summary) != 1 || r.Summary[0].Present != 1 || r.Summary[0].Absent != 1 {
+		t.Errorf("Summary = %+v, want 1 present and 1 absent day", r.Summary)
+	}
+}
+
+func TestReportAttendanceCmd_write(t *testing.T) {
+	report := testAttendanceReport(t)
+
+	t.Run("xlsx", func(t *testing.T) {
+		var b bytes.Buffer
+		r := &ReportAttendanceCmd{Output: "report.XLSX"}
+		if err := r.write(&CliContext{}, &b, report); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+
+		files := readXLSX(t, b.Bytes())
+		workbook := files["xl/workbook.xml"]
+		if !strings.Contains(workbook, `<sheet name="Summary" sheetId="1"`) || !strings.Contains(workbook, `<sheet name="Detail" sheetId="2"`) {
+			t.Errorf("workbook.xml = %s, want the Summary and Detail sheets", workbook)
+		}
+
+		summary := files["xl/worksheets/sheet1.xml"]
+		for _, want := range []string{
+			`<c r="A1" t="inlineStr"><is><t xml:space="preserve">AliasID</t></is></c>`,
+			`<c r="B2" t="inlineStr"><is><t xml:space="preserve">Nguyễn &lt;A&gt;</t></is></c>`,
+			`<c r="E2"><v>1</v></c>`,
+			`<c r="F2"><v>1</v></c>`,
+		} {
+			if !strings.Contains(summary, want) {
+				t.Errorf("Summary sheet is missing %s", want)
+			}
+		}
+
+		detail := files["xl/worksheets/sheet2.xml"]
+		for _, want := range []string{
+			`<c r="E2" t="inlineStr"><is><t xml:space="preserve">2021-07-26 22:00:00</t></is></c>`,
+			`<c r="H3" t="inlineStr"><is><t xml:space="preserve">absent</t></is></c>`,
+			`<row r="3">`,
+		} {
+			if !strings.Contains(detail, want) {
+				t.Errorf("Detail sheet is missing %s", want)
+			}
+		}
+		if strings.Contains(detail, `<row r="4">`) {
+			t.Errorf("Detail sheet has more than 2 days")
+		}
+	})
+
+	t.Run("csv summary", func(t *testing.T) {
+		var b bytes.Buffer
+		r := &ReportAttendanceCmd{Summary: true}
+		if err := r.write(&CliContext{NoHeader: true}, &b, report); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+
+		if got, want := b.String(), "A,Nguyễn <A>,night,2,1,1,0,0,0,0,0,0,480\n"; got != want {
+			t.Errorf("write() = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"giautm.dev/hanetai"
+	"giautm.dev/hanetai/roster"
+)
+
+type PersonSyncCmd struct {
+	File        *os.File `kong:"required,name='file',help:'The roster CSV file (aliasID,name,title,type,photo)'"`
+	PlaceID     int      `kong:"required,name='place-id',help:'The place to sync persons'"`
+	PersonType  string   `kong:"optional,name='type',help:'Only delete persons of this type, defaults to employees (0)'"`
+	DryRun      bool     `kong:"optional,name='dry-run',help:'Print the plan without applying it'"`
+	NoDelete    bool     `kong:"optional,name='no-delete',help:'Keep persons that are not in the roster'"`
+	UpdateFaces bool     `kong:"optional,name='update-faces',help:'Replace the face of all the existing persons with their photo, once after the photos changed as it uploads every face'"`
+	Concurrency int      `kong:"optional,name='concurrency',help:'Number of concurrent requests',default:'4'"`
+}
+
+type personSyncResult struct {
+	Action   roster.ActionType `json:"action"`
+	AliasID  string            `json:"aliasID"`
+	Name     string            `json:"name"`
+	PersonID string            `json:"personID,omitempty"`
+	Status   string            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+}
+
+func (r *PersonSyncCmd) Run(ctx *CliContext) error {
+	defer r.File.Close()
+	persons, err := roster.ReadCSV(r.File)
+	if err != nil {
+		return err
+	}
+
+	c := ctx.NewClient()
+	rec := &roster.Reconciler{
+		Persons:     c.Persons,
+		PersonType:  r.PersonType,
+		Concurrency: r.Concurrency,
+		DryRun:      r.DryRun,
+		NoDelete:    r.NoDelete,
+	}
+	if r.UpdateFaces {
+		rec.FaceChanged = func(hanetai.PersonListItem, roster.Person) bool {
+			return true
+		}
+	}
+
+	plan, results, err := rec.Sync(ctx.Context, r.PlaceID, persons)
+	if err != nil {
+		return err
+	}
+
+	items := make([]personSyncResult, len(plan.Actions))
+	failed := 0
+	for i, a := range plan.Actions {
+		items[i] = personSyncResult{
+			Action:  a.Type,
+			AliasID: a.Person.AliasID,
+			Name:    a.Person.Name,
+			Status:  "planned",
+		}
+		if results == nil {
+			continue
+		}
+		if err := results[i].Err; err != nil {
+			failed++
+			items[i].Status = "failed"
+			items[i].Error = err.Error()
+		} else {
+			items[i].Status = "done"
+			items[i].PersonID = results[i].PersonID
+		}
+	}
+
+	if err := r.print(ctx, items); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d actions failed", failed, len(items))
+	}
+	return nil
+}
+
+func (r *PersonSyncCmd) print(ctx *CliContext, items []personSyncResult) (err error) {
+	if ctx.JSON {
+		return json.NewEncoder(ctx.Writer()).Encode(items)
+	}
+
+	s := csv.NewWriter(ctx.Writer())
+	defer s.Flush()
+
+	if !ctx.NoHeader {
+		err = s.Write([]string{
+			"Action",
+			"AliasID",
+			"Name",
+			"PersonID",
+			"Status",
+			"Error",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, i := range items {
+		err = s.Write([]string{
+			string(i.Action),
+			i.AliasID,
+			i.Name,
+			i.PersonID,
+			i.Status,
+			i.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"giautm.dev/hanetai"
+	"golang.org/x/oauth2"
+)
+
+var errNotLoggedIn = errors.New("not logged in, run `hanet auth login` or set HANET_ACCESS_TOKEN")
+
+// tokenFile is the per-user file keeping the token of `hanet auth login`,
+// the client credentials are kept along so the token can be refreshed.
+type tokenFile struct {
+	ClientID     string        `json:"client_id"`
+	ClientSecret string        `json:"client_secret"`
+	RedirectURL  string        `json:"redirect_url"`
+	Token        *oauth2.Token `json:"token"`
+
+	// AuthURL and TokenURL override the endpoints of Hanet, e.g. for a
+	// proxy or a test server.
+	AuthURL  string `json:"auth_url,omitempty"`
+	TokenURL string `json:"token_url,omitempty"`
+}
+
+// tokenPath returns the path of the token file, $HANET_CONFIG_DIR/token.json
+// or hanet/token.json in the user config directory.
+func tokenPath() (string, error) {
+	dir := os.Getenv("HANET_CONFIG_DIR")
+	if dir == "" {
+		d, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(d, "hanet")
+	}
+
+	return filepath.Join(dir, "token.json"), nil
+}
+
+func loadTokenFile() (*tokenFile, error) {
+	path, err := tokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f tokenFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if f.Token == nil {
+		return nil, errNotLoggedIn
+	}
+	return &f, nil
+}
+
+// save writes the file readable only by the user, through a temporary file
+// so a crash doesn't leave a truncated token behind.
+func (f *tokenFile) save() error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".token-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *tokenFile) config() *oauth2.Config {
+	c := hanetai.NewOAuth2Config(f.ClientID, f.ClientSecret, f.RedirectURL)
+	if f.AuthURL != "" {
+		c.Endpoint.AuthURL = f.AuthURL
+	}
+	if f.TokenURL != "" {
+		c.Endpoint.TokenURL = f.TokenURL
+	}
+	return c
+}
+
+var _ oauth2.TokenSource = (*persistentTokenSource)(nil)
+
+// persistentTokenSource refreshes the token of the token file when it
+// expires, and writes the new token back to the file.
+type persistentTokenSource struct {
+	ctx context.Context
+
+	mu   sync.Mutex
+	file *tokenFile
+	src  oauth2.TokenSource
+}
+
+func (s *persistentTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		f, err := loadTokenFile()
+		if err != nil {
+			return nil, err
+		}
+		s.file = f
+		s.src = f.config().TokenSource(s.ctx, f.Token)
+	}
+
+	t, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if t.AccessToken != s.file.Token.AccessToken {
+		s.file.Token = t
+		if err := s.file.save(); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// setConfigDir points HANET_CONFIG_DIR to a temporary directory for the
+// test.
+func setConfigDir(t *testing.T) {
+	old, ok := os.LookupEnv("HANET_CONFIG_DIR")
+	os.Setenv("HANET_CONFIG_DIR", t.TempDir())
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("HANET_CONFIG_DIR", old)
+		} else {
+			os.Unsetenv("HANET_CONFIG_DIR")
+		}
+	})
+}
+
+func TestPersistentTokenSource_NotLoggedIn(t *testing.T) {
+	setConfigDir(t)
+
+	s := &persistentTokenSource{ctx: context.Background()}
+	if _, err := s.Token(); !errors.Is(err, errNotLoggedIn) {
+		t.Errorf("Token() error = %v, want %v", err, errNotLoggedIn)
+	}
+}
+
+func TestPersistentTokenSource_Refresh(t *testing.T) {
+	setConfigDir(t)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		if got := r.PostForm.Get("refresh_token"); got != "refresh" {
+			t.Errorf("refresh_token = %q, want %q", got, "refresh")
+		}
+		if got := r.PostForm.Get("client_id"); got != "client" {
+			t.Errorf("client_id = %q, want %q", got, "client")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"new","token_type":"Bearer","refresh_token":"refresh2","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	f := &tokenFile{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		TokenURL:     srv.URL,
+		Token: &oauth2.Token{
+			AccessToken:  "old",
+			RefreshToken: "refresh",
+			Expiry:       time.Now().Add(-time.Hour),
+		},
+	}
+	if err := f.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &persistentTokenSource{ctx: context.Background()}
+	for i := 0; i < 2; i++ {
+		tok, err := s.Token()
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if tok.AccessToken != "new" {
+			t.Errorf("Token() = %q, want %q", tok.AccessToken, "new")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("token endpoint calls = %d, want 1", calls)
+	}
+
+	saved, err := loadTokenFile()
+	if err != nil {
+		t.Fatalf("loadTokenFile() error = %v", err)
+	}
+	if saved.Token.AccessToken != "new" || saved.Token.RefreshToken != "refresh2" || saved.TokenURL != srv.URL {
+		t.Errorf("saved token = %+v, want the refreshed token", saved)
+	}
+
+	path, _ := tokenPath()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("token file mode = %v, want 0600", perm)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"giautm.dev/hanetai/webhook"
+	"giautm.dev/hanetai/webhook/webhooktest"
+)
+
+type WebhookSendCmd struct {
+	URL         string   `kong:"required,name='url',help:'The URL of the webhook receiver'"`
+	Secret      string   `kong:"required,name='secret',env='HANET_CLIENT_SECRET',help:'The client secret signing the events'"`
+	DataType    string   `kong:"optional,name='type',help:'The data type of the event: log, checkin_picture, person, place or device'"`
+	ActionType  string   `kong:"optional,name='action',help:'The action type of the event: add, update or delete'"`
+	Count       int      `kong:"optional,name='count',help:'The number of events to send'"`
+	Concurrency int      `kong:"optional,name='concurrency',help:'Number of concurrent requests'"`
+	Script      *os.File `kong:"optional,name='script',help:'A JSONL script of the events to send'"`
+}
+
+type webhookSendResult struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (r *WebhookSendCmd) Run(ctx *CliContext) error {
+	s := &webhooktest.Sender{
+		URL:    r.URL,
+		Secret: []byte(r.Secret),
+	}
+
+	w := csv.NewWriter(ctx.Writer())
+	enc := json.NewEncoder(ctx.Writer())
+	if !ctx.JSON && !ctx.NoHeader {
+		if err := w.Write([]string{"ID", "Status", "Duration", "Error"}); err != nil {
+			return err
+		}
+	}
+
+	failed, total := 0, 0
+	print := func(res webhooktest.Result) error {
+		total++
+		item := webhookSendResult{
+			ID:       res.ID,
+			Status:   "sent",
+			Duration: res.Duration.Round(time.Millisecond).String(),
+		}
+		if res.Err != nil {
+			failed++
+			item.Status = "failed"
+			item.Error = res.Err.Error()
+		}
+
+		if ctx.JSON {
+			return enc.Encode(item)
+		}
+		err := w.Write([]string{item.ID, item.Status, item.Duration, item.Error})
+		w.Flush()
+		if err == nil {
+			err = w.Error()
+		}
+		return err
+	}
+
+	if r.Script != nil {
+		defer r.Script.Close()
+		if err := s.Script(ctx.Context, r.Script, print); err != nil {
+			return err
+		}
+	} else {
+		dataType := webhook.DataType(r.DataType)
+		if dataType == "" {
+			dataType = webhook.DataLog
+		}
+		count := r.Count
+		if count < 1 {
+			count = 1
+		}
+
+		results := s.Burst(ctx.Context, count, r.Concurrency, func(int) *webhook.Data {
+			return webhooktest.NewEvent(dataType, webhook.ActionType(r.ActionType))
+		})
+		for _, res := range results {
+			if err := print(res); err != nil {
+				return err
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events failed", failed, total)
+	}
+	return nil
+}
+
+type WebhookServeCmd struct {
+	Addr       string   `kong:"optional,name='addr',help:'The address to listen on, defaults to :8080'"`
+	Path       string   `kong:"optional,name='path',help:'The path of the webhook, defaults to /'"`
+	Secret     string   `kong:"optional,name='secret',env='HANET_CLIENT_SECRET',help:'The client secret verifying the events'"`
+	PlaceIDs   []int    `kong:"optional,name='place-id',help:'Only show the events of these places'"`
+	DeviceIDs  []string `kong:"optional,name='device-id',help:'Only show the events of these devices'"`
+	PersonType []string `kong:"optional,name='person-type',help:'Only show the events of these person types, e.g. 0 or employee'"`
+	Output     string   `kong:"optional,name='output',help:'Append the events to a JSONL file, replayable with webhook send --script, which signs them again and keeps their time'"`
+}
+
+func (r *WebhookServeCmd) Run(ctx *CliContext) error {
+	addr := r.Addr
+	if addr == "" {
+		addr = ":8080"
+	}
+	path := r.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var out *os.File
+	if r.Output != "" {
+		f, err := os.OpenFile(r.Output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var mu sync.Mutex
+	handler := webhook.HandlerFunc(func(_ context.Context, data *webhook.Data) error {
+		if !r.match(data) {
+			return nil
+		}
+		raw, err := io.ReadAll(data.RawData)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if out != nil {
+			event, err := replayableEvent(raw)
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(out).Encode(webhooktest.Step{Event: event}); err != nil {
+				return err
+			}
+		}
+		if ctx.JSON {
+			_, err = fmt.Fprintf(ctx.Writer(), "%s\n", bytes.TrimSpace(raw))
+			return err
+		}
+		_, err = fmt.Fprintln(ctx.Writer(), describeEvent(data))
+		return err
+	})
+
+	opts := []webhook.Option{
+		webhook.WithOnError(func(_ context.Context, err error) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}),
+	}
+	if r.Secret != "" {
+		opts = append(opts, webhook.WithSecretVerify([]byte(r.Secret)))
+	} else {
+		fmt.Fprintln(os.Stderr, "warning: no secret given, the events are not verified")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, webhook.NewHTTPHandler(handler, opts...))
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	sctx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Listening on %s%s\n", addr, path)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-sctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
+
+func (r *WebhookServeCmd) match(data *webhook.Data) bool {
+	if len(r.PlaceIDs) > 0 {
+		if data.PlaceData == nil || !containsInt(r.PlaceIDs, data.PlaceID.Int()) {
+			return false
+		}
+	}
+	if len(r.DeviceIDs) > 0 {
+		if data.DeviceData == nil || !containsString(r.DeviceIDs, data.DeviceID) {
+			return false
+		}
+	}
+	if len(r.PersonType) > 0 {
+		if data.PersonData == nil {
+			return false
+		}
+		ok := false
+		for _, t := range r.PersonType {
+			if t == string(data.PersonType) || strings.EqualFold(t, data.PersonType.String()) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// replayableEvent removes the signature of the raw event, so webhook send
+// signs it again with its own secret. The ID and the time are kept, so the
+// replayed check-ins keep their original time.
+func replayableEvent(raw []byte) (json.RawMessage, error) {
+	fields := map[string]interface{}{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "hash")
+
+	return json.Marshal(fields)
+}
+
+// describeEvent returns a line describing the event for humans.
+func describeEvent(data *webhook.Data) string {
+	var b strings.Builder
+
+	if t, ok := data.EventTime(); ok {
+		b.WriteString(t.Local().Format("2006-01-02 15:04:05"))
+	} else {
+		b.WriteString("-")
+	}
+
+	dataType, actionType := data.Event().Type()
+	b.WriteString("  " + string(dataType))
+	if actionType != "" {
+		b.WriteString("/" + string(actionType))
+	}
+
+	if data.PlaceData != nil {
+		fmt.Fprintf(&b, "  place=%q (%d)", data.PlaceName, data.PlaceID.Int())
+	}
+	if data.DeviceData != nil {
+		fmt.Fprintf(&b, "  device=%q (%s)", data.DeviceName, data.DeviceID)
+	}
+	if data.PersonData != nil {
+		fmt.Fprintf(&b, "  person=%q (%s, %s)", data.PersonName, data.AliasID, data.PersonType)
+	}
+	if data.EventData != nil {
+		fmt.Fprintf(&b, "  id=%s", data.ID)
+	}
+	return b.String()
+}
+
+func containsInt(s []int, v int) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
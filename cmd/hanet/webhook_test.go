@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestReplayableEvent(t *testing.T) {
+	raw := []byte(`{"data_type":"log","id":"1","hash":"a173b27d031519da1e0cc5468eb7b9f3","time":1627271289000,"date":"2021-07-26 10:48:09","personName":"Nguyễn Văn A"}`)
+
+	got, err := replayableEvent(raw)
+	if err != nil {
+		t.Fatalf("replayableEvent() error = %v", err)
+	}
+	want := `{"data_type":"log","date":"2021-07-26 10:48:09","id":"1","personName":"Nguyễn Văn A","time":1627271289000}`
+	if string(got) != want {
+		t.Errorf("replayableEvent() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{i: 0, want: "A"},
+		{i: 25, want: "Z"},
+		{i: 26, want: "AA"},
+		{i: 51, want: "AZ"},
+		{i: 52, want: "BA"},
+		{i: 701, want: "ZZ"},
+		{i: 702, want: "AAA"},
+	}
+	for _, tt := range tests {
+		if got := xlsxColumn(tt.i); got != tt.want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestXMLEscape(t *testing.T) {
+	if got, want := xmlEscape(`Nguyễn <"A"> & 'B'`), "Nguyễn &lt;&#34;A&#34;&gt; &amp; &#39;B&#39;"; got != want {
+		t.Errorf("xmlEscape() = %q, want %q", got, want)
+	}
+}
+
+// readXLSX returns the files of the workbook written in b.
+func readXLSX(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := xml.Unmarshal(body, new(interface{})); err != nil {
+			t.Errorf("%s is not valid XML: %v", f.Name, err)
+		}
+		files[f.Name] = string(body)
+	}
+	return files
+}
+
+func TestWriteXLSX(t *testing.T) {
+	row := make([]interface{}, 28)
+	for i := range row {
+		row[i] = i
+	}
+	row[27] = "a < b & c"
+
+	var b bytes.Buffer
+	err := writeXLSX(&b, []xlsxSheet{
+		{Name: "R&D", Rows: [][]interface{}{{"Name", 1.5}, row}},
+	})
+	if err != nil {
+		t.Fatalf("writeXLSX() error = %v", err)
+	}
+
+	files := readXLSX(t, b.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("writeXLSX() is missing %s", name)
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `<sheet name="R&amp;D" sheetId="1" r:id="rId1"/>`) {
+		t.Errorf("workbook.xml = %s, want the escaped sheet name", files["xl/workbook.xml"])
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">Name</t></is></c>`,
+		`<c r="B1"><v>1.5</v></c>`,
+		`<c r="Z2"><v>25</v></c>`,
+		`<c r="AA2"><v>26</v></c>`,
+		`<c r="AB2" t="inlineStr"><is><t xml:space="preserve">a &lt; b &amp; c</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet1.xml is missing %s", want)
+		}
+	}
+}
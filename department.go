@@ -0,0 +1,120 @@
+package hanetai
+
+import (
+	"context"
+)
+
+type DepartmentService service
+
+type Department struct {
+	ID      int    `json:"id" url:"id,omitempty"`
+	PlaceID int    `json:"placeID" url:"placeID,omitempty"`
+	Name    string `json:"name" url:"name,omitempty"`
+	Desc    string `json:"desc" url:"desc,omitempty"`
+}
+
+func (s *DepartmentService) Add(ctx context.Context, department Department) (*Department, error) {
+	req, err := s.client.NewRequest("department/add", urlencodeBody(department))
+	if err != nil {
+		return nil, err
+	}
+
+	var i Department
+	_, err = s.client.Do(ctx, req, &i)
+	if err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// DepartmentUpdateRequest changes the fields of a department that are not
+// nil, an empty string clears the field.
+type DepartmentUpdateRequest struct {
+	ID   int     `url:"id"`
+	Name *string `url:"name,omitempty"`
+	Desc *string `url:"desc,omitempty"`
+}
+
+func (s *DepartmentService) Update(ctx context.Context, data DepartmentUpdateRequest) error {
+	req, err := s.client.NewRequest("department/update", urlencodeBody(data))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, req, nil)
+	return err
+}
+
+type DepartmentRemoveRequest struct {
+	ID int `url:"id"`
+}
+
+func (s *DepartmentService) Remove(ctx context.Context, data DepartmentRemoveRequest) error {
+	req, err := s.client.NewRequest("department/remove", urlencodeBody(data))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, req, nil)
+	return err
+}
+
+type DepartmentListRequest struct {
+	PlaceID int `url:"placeID"`
+}
+
+func (s *DepartmentService) List(ctx context.Context, data DepartmentListRequest) ([]Department, error) {
+	req, err := s.client.NewRequest("department/list", urlencodeBody(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var a []Department
+	_, err = s.client.Do(ctx, req, &a)
+	return a, err
+}
+
+type DepartmentPersonsRequest struct {
+	ID       int      `url:"id"`
+	PlaceID  int      `url:"placeID"`
+	AliasIDs []string `url:"aliasIDs,comma"`
+}
+
+// AddPersons assigns the persons of the place to the department.
+func (s *DepartmentService) AddPersons(ctx context.Context, data DepartmentPersonsRequest) error {
+	req, err := s.client.NewRequest("department/addPerson", urlencodeBody(data))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, req, nil)
+	return err
+}
+
+// RemovePersons removes the persons of the place from the department.
+func (s *DepartmentService) RemovePersons(ctx context.Context, data DepartmentPersonsRequest) error {
+	req, err := s.client.NewRequest("department/removePerson", urlencodeBody(data))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, req, nil)
+	return err
+}
+
+type DepartmentListPersonRequest struct {
+	ID      int `url:"id"`
+	PlaceID int `url:"placeID"`
+}
+
+func (s *DepartmentService) ListPersons(ctx context.Context, data DepartmentListPersonRequest) ([]PersonListItem, error) {
+	req, err := s.client.NewRequest("department/listPerson", urlencodeBody(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var a []PersonListItem
+	_, err = s.client.Do(ctx, req, &a)
+	return a, err
+}
@@ -0,0 +1,147 @@
+package hanetai
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"giautm.dev/hanetai/hanettest"
+)
+
+func TestDepartmentService_Add(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	type args struct {
+		ctx        context.Context
+		department Department
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Department
+		wantErr bool
+	}{
+		{
+			name: "happy case",
+			args: args{
+				ctx: context.Background(),
+				department: Department{
+					PlaceID: 1542,
+					Name:    "Kế toán",
+					Desc:    "Phòng kế toán",
+				},
+			},
+			want: &Department{
+				ID:      1001,
+				PlaceID: 1542,
+				Name:    "Kế toán",
+				Desc:    "Phòng kế toán",
+			},
+		},
+		{
+			name: "unknown place",
+			args: args{
+				ctx: context.Background(),
+				department: Department{
+					PlaceID: 1,
+					Name:    "Kế toán",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Departments.Add(tt.args.ctx, tt.args.department)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DepartmentService.Add() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DepartmentService.Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDepartmentService_UpdateRemove(t *testing.T) {
+	c, srv := newTestClient(t)
+	d := srv.AddDepartment(hanettest.Department{
+		PlaceID: 1542,
+		Name:    "Kế toán",
+		Desc:    "Tầng 2",
+	})
+	ctx := context.Background()
+
+	name, desc := "Tài chính", ""
+	err := c.Departments.Update(ctx, DepartmentUpdateRequest{
+		ID:   d.ID,
+		Name: &name,
+		Desc: &desc,
+	})
+	if err != nil {
+		t.Fatalf("DepartmentService.Update() error = %v", err)
+	}
+
+	got, err := c.Departments.List(ctx, DepartmentListRequest{PlaceID: 1542})
+	if err != nil {
+		t.Fatalf("DepartmentService.List() error = %v", err)
+	}
+	want := []Department{{ID: d.ID, PlaceID: 1542, Name: "Tài chính"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DepartmentService.List() = %v, want %v", got, want)
+	}
+
+	desc = "Tầng 3"
+	if err := c.Departments.Update(ctx, DepartmentUpdateRequest{ID: d.ID, Desc: &desc}); err != nil {
+		t.Fatalf("DepartmentService.Update() error = %v", err)
+	}
+	if got := srv.Departments(1542); len(got) != 1 || got[0].Name != "Tài chính" || got[0].Desc != "Tầng 3" {
+		t.Errorf("Departments() = %v, want the name kept", got)
+	}
+
+	if err := c.Departments.Remove(ctx, DepartmentRemoveRequest{ID: d.ID}); err != nil {
+		t.Fatalf("DepartmentService.Remove() error = %v", err)
+	}
+	if got := srv.Departments(1542); len(got) != 0 {
+		t.Errorf("Departments() = %v, want empty", got)
+	}
+}
+
+func TestDepartmentService_Persons(t *testing.T) {
+	c, srv := newTestClient(t)
+	d := srv.AddDepartment(hanettest.Department{
+		PlaceID: 1542,
+		Name:    "Kế toán",
+	})
+	ctx := context.Background()
+
+	err := c.Departments.AddPersons(ctx, DepartmentPersonsRequest{
+		ID:       d.ID,
+		PlaceID:  1542,
+		AliasIDs: []string{"VCFL1231231", "852576"},
+	})
+	if err != nil {
+		t.Fatalf("DepartmentService.AddPersons() error = %v", err)
+	}
+
+	err = c.Departments.RemovePersons(ctx, DepartmentPersonsRequest{
+		ID:       d.ID,
+		PlaceID:  1542,
+		AliasIDs: []string{"852576"},
+	})
+	if err != nil {
+		t.Fatalf("DepartmentService.RemovePersons() error = %v", err)
+	}
+
+	got, err := c.Departments.ListPersons(ctx, DepartmentListPersonRequest{
+		ID:      d.ID,
+		PlaceID: 1542,
+	})
+	if err != nil {
+		t.Fatalf("DepartmentService.ListPersons() error = %v", err)
+	}
+	if len(got) != 1 || got[0].AliasID != "VCFL1231231" {
+		t.Errorf("DepartmentService.ListPersons() = %v, want VCFL1231231 only", got)
+	}
+}
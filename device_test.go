@@ -7,6 +7,8 @@ import (
 )
 
 func TestDeviceService_GetConnectionStatus(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -24,7 +26,7 @@ func TestDeviceService_GetConnectionStatus(t *testing.T) {
 		{
 			name: "Happy Case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -61,6 +63,8 @@ func TestDeviceService_GetConnectionStatus(t *testing.T) {
 }
 
 func TestDeviceService_GetListDevices(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -77,12 +81,22 @@ func TestDeviceService_GetListDevices(t *testing.T) {
 		{
 			name: "Happy Case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
 			},
-			want: nil,
+			want: &ListDevicesResponse{
+				Devices: []DeviceInfo{
+					{
+						DeviceID:   "C21024B155",
+						DeviceName: "Front door",
+						Address:    "Hà Nội",
+						PlaceID:    1542,
+						PlaceName:  "Hanet HQ",
+					},
+				},
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -103,6 +117,8 @@ func TestDeviceService_GetListDevices(t *testing.T) {
 }
 
 func TestDeviceService_GetListDevicesByPlace(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -120,13 +136,25 @@ func TestDeviceService_GetListDevicesByPlace(t *testing.T) {
 		{
 			name: "Happy Case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
-				ctx:  context.Background(),
-				data: &ListDevicesByPlaceRequest{},
+				ctx: context.Background(),
+				data: &ListDevicesByPlaceRequest{
+					PlaceID: 1542,
+				},
+			},
+			want: &ListDevicesResponse{
+				Devices: []DeviceInfo{
+					{
+						DeviceID:   "C21024B155",
+						DeviceName: "Front door",
+						Address:    "Hà Nội",
+						PlaceID:    1542,
+						PlaceName:  "Hanet HQ",
+					},
+				},
 			},
-			want: nil,
 		},
 	}
 	for _, tt := range tests {
@@ -147,6 +175,8 @@ func TestDeviceService_GetListDevicesByPlace(t *testing.T) {
 }
 
 func TestDeviceService_UpdateDevice(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -163,11 +193,14 @@ func TestDeviceService_UpdateDevice(t *testing.T) {
 		{
 			name: "Happy Case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
-				ctx:  context.Background(),
-				data: &UpdateDeviceRequest{},
+				ctx: context.Background(),
+				data: &UpdateDeviceRequest{
+					DeviceID:   "C21024B155",
+					DeviceName: "Cửa trước",
+				},
 			},
 		},
 	}
@@ -1,6 +1,14 @@
 package hanetai
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
 
 type ServerError struct {
 	Code    int
@@ -22,6 +30,17 @@ const (
 	errCodeDuplicatedImage  = -9007
 )
 
+// StatusError is an HTTP response without the API envelope, e.g. a 502
+// from a proxy in front of the API.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("hanet: %s", e.Status)
+}
+
 // IsRetriable checks if a given error is an Hanet retriable error
 func IsRetriable(err error) bool {
 	if err == nil {
@@ -37,3 +56,22 @@ func IsRetriable(err error) bool {
 
 	return true
 }
+
+// isTransient reports whether a request failing with err may succeed when
+// sent again: the transport errors and the 429 and 5xx responses. The API
+// errors are not, as Hanet documents no transient return code, see
+// RetryPolicy.RetriableCodes.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+	}
+
+	var ue *url.Error
+	var ne net.Error
+	return errors.As(err, &ue) || errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,310 @@
+package hanetai
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // register PNG decoder
+	"net/http"
+)
+
+// FaceImage is a face photo ready to upload.
+type FaceImage struct {
+	Data        []byte
+	FileName    string
+	ContentType string
+}
+
+// FacePreprocessor prepares face photos before they are uploaded, so Hanet
+// doesn't reject them (-9006, -5010) for their size or orientation.
+//
+// JPEG and PNG photos are decoded, rotated according to their EXIF
+// orientation, downscaled to fit Size and re-encoded as JPEG under MaxBytes.
+type FacePreprocessor struct {
+	// Size is the bounding box of the photo, DefaultAvatarSize when zero.
+	// The box is matched to the orientation of the photo, so a portrait
+	// photo fits in a portrait box.
+	Size AvatarSize
+
+	// MaxBytes is the size budget of the encoded JPEG, 1 MiB when zero.
+	MaxBytes int
+
+	// Quality is the initial JPEG quality, lowered until the photo fits in
+	// MaxBytes. Defaults to 90.
+	Quality int
+}
+
+// ErrFaceTooLarge is returned when a photo can't be encoded under the size
+// budget of the FacePreprocessor.
+var ErrFaceTooLarge = errors.New("hanet: face image exceeds the size budget")
+
+const (
+	defaultFaceMaxBytes = 1 << 20
+	defaultFaceQuality  = 90
+	minFaceQuality      = 40
+)
+
+// Process decodes the photo and returns it as a JPEG ready to upload.
+func (p *FacePreprocessor) Process(data []byte) (*FaceImage, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	img := flatten(src)
+	img = orient(img, exifOrientation(data))
+	img = fit(img, p.size())
+
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultFaceMaxBytes
+	}
+	quality := p.Quality
+	if quality <= 0 || quality > 100 {
+		quality = defaultFaceQuality
+	}
+
+	for {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		if buf.Len() <= maxBytes {
+			return &FaceImage{
+				Data:        buf.Bytes(),
+				FileName:    "avatar.jpg",
+				ContentType: "image/jpeg",
+			}, nil
+		}
+
+		// Lower the quality first, then the resolution.
+		if quality > minFaceQuality {
+			quality -= 10
+			continue
+		}
+		b := img.Bounds()
+		if b.Dx() < 64 || b.Dy() < 64 {
+			return nil, ErrFaceTooLarge
+		}
+		img = resize(img, b.Dx()*3/4, b.Dy()*3/4)
+	}
+}
+
+func (p *FacePreprocessor) size() AvatarSize {
+	if p.Size.Height <= 0 || p.Size.Width <= 0 {
+		return DefaultAvatarSize
+	}
+	return p.Size
+}
+
+// prepare returns the image to upload, p may be nil to upload the data as
+// is with a filename and content type matching its content.
+func (p *FacePreprocessor) prepare(data []byte) (*FaceImage, error) {
+	if p != nil {
+		return p.Process(data)
+	}
+
+	contentType := http.DetectContentType(data)
+	name := fileName
+	switch contentType {
+	case "image/jpeg":
+		name = "avatar.jpg"
+	case "image/png":
+		name = "avatar.png"
+	case "image/gif":
+		name = "avatar.gif"
+	case "image/webp":
+		name = "avatar.webp"
+	}
+
+	return &FaceImage{
+		Data:        data,
+		FileName:    name,
+		ContentType: contentType,
+	}, nil
+}
+
+// flatten draws the image on a white background, JPEG has no transparency.
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) to the image.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	// from maps a pixel of the destination to the source.
+	var from func(x, y int) (int, int)
+	switch orientation {
+	case 2: // flip horizontal
+		from = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3: // rotate 180
+		from = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4: // flip vertical
+		from = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5: // transpose
+		from = func(x, y int) (int, int) { return y, x }
+	case 6: // rotate 90 clockwise
+		from = func(x, y int) (int, int) { return y, h - 1 - x }
+	case 7: // transverse
+		from = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case 8: // rotate 90 counter-clockwise
+		from = func(x, y int) (int, int) { return w - 1 - y, x }
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := from(x, y)
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// fit downscales the image to fit in the box, keeping its aspect ratio.
+func fit(src *image.RGBA, box AvatarSize) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	long, short := box.Height, box.Width
+	if short > long {
+		long, short = short, long
+	}
+	maxW, maxH := short, long
+	if w > h {
+		maxW, maxH = long, short
+	}
+	if w <= maxW && h <= maxH {
+		return src
+	}
+
+	dw, dh := maxW, h*maxW/w
+	if dh > maxH {
+		dw, dh = w*maxH/h, maxH
+	}
+	return resize(src, dw, dh)
+}
+
+// resize downscales the image with a box filter.
+func resize(src *image.RGBA, dw, dh int) *image.RGBA {
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, (y+1)*h/dh
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, (x+1)*w/dw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			di := dst.PixOffset(x, y)
+			dst.Pix[di] = uint8(r / n)
+			dst.Pix[di+1] = uint8(g / n)
+			dst.Pix[di+2] = uint8(b / n)
+			dst.Pix[di+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// exifOrientation returns the orientation tag of a JPEG, or 1 if the data
+// is not a JPEG or has no orientation.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,232 @@
+package hanetai
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"testing"
+)
+
+// withOrientation inserts an EXIF segment with the orientation tag after
+// the SOI marker of a JPEG.
+func withOrientation(data []byte, order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 0x2A)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func testImage(w, h int, noise bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255}
+			if noise {
+				c = color.RGBA{R: uint8(r.Intn(256)), G: uint8(r.Intn(256)), B: uint8(r.Intn(256)), A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_exifOrientation(t *testing.T) {
+	data := encodeJPEG(t, testImage(8, 8, false))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{
+			name: "no exif",
+			data: data,
+			want: 1,
+		},
+		{
+			name: "little endian",
+			data: withOrientation(data, binary.LittleEndian, 6),
+			want: 6,
+		},
+		{
+			name: "big endian",
+			data: withOrientation(data, binary.BigEndian, 8),
+			want: 8,
+		},
+		{
+			name: "not a jpeg",
+			data: []byte("GIF89a"),
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("exifOrientation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFacePreprocessor_Process(t *testing.T) {
+	type fields struct {
+		Size     AvatarSize
+		MaxBytes int
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		data   []byte
+		wantW  int
+		wantH  int
+	}{
+		{
+			name:  "landscape png is downscaled",
+			data:  encodePNG(t, testImage(2560, 1280, false)),
+			wantW: 1280,
+			wantH: 640,
+		},
+		{
+			name:  "portrait jpeg fits a portrait box",
+			data:  encodeJPEG(t, testImage(1000, 2000, false)),
+			wantW: 640,
+			wantH: 1280,
+		},
+		{
+			name:  "exif rotation is applied",
+			data:  withOrientation(encodeJPEG(t, testImage(200, 100, false)), binary.LittleEndian, 6),
+			wantW: 100,
+			wantH: 200,
+		},
+		{
+			name: "size budget",
+			fields: fields{
+				Size:     AvatarSize{Height: 400, Width: 400},
+				MaxBytes: 20 << 10,
+			},
+			data:  encodePNG(t, testImage(400, 400, true)),
+			wantW: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &FacePreprocessor{
+				Size:     tt.fields.Size,
+				MaxBytes: tt.fields.MaxBytes,
+			}
+			got, err := p.Process(tt.data)
+			if err != nil {
+				t.Fatalf("FacePreprocessor.Process() error = %v", err)
+			}
+			if got.ContentType != "image/jpeg" || got.FileName != "avatar.jpg" {
+				t.Errorf("FacePreprocessor.Process() = %v %v, want avatar.jpg image/jpeg", got.FileName, got.ContentType)
+			}
+			if tt.fields.MaxBytes > 0 && len(got.Data) > tt.fields.MaxBytes {
+				t.Errorf("FacePreprocessor.Process() size = %v, want <= %v", len(got.Data), tt.fields.MaxBytes)
+			}
+
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(got.Data))
+			if err != nil {
+				t.Fatalf("jpeg.DecodeConfig() error = %v", err)
+			}
+			if tt.wantW >= 0 && (cfg.Width != tt.wantW || cfg.Height != tt.wantH) {
+				t.Errorf("FacePreprocessor.Process() = %vx%v, want %vx%v", cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestPersonService_Register_FacePreprocessor(t *testing.T) {
+	tests := []struct {
+		name            string
+		preprocessor    *FacePreprocessor
+		data            []byte
+		wantFileName    string
+		wantContentType string
+	}{
+		{
+			name:            "png as is",
+			data:            encodePNG(t, testImage(16, 16, false)),
+			wantFileName:    "avatar.png",
+			wantContentType: "image/png",
+		},
+		{
+			name:            "png converted to jpeg",
+			preprocessor:    &FacePreprocessor{},
+			data:            encodePNG(t, testImage(16, 16, false)),
+			wantFileName:    "avatar.jpg",
+			wantContentType: "image/jpeg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newHandlerTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				_, h, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("FormFile() error = %v", err)
+					return
+				}
+				if h.Filename != tt.wantFileName {
+					t.Errorf("filename = %v, want %v", h.Filename, tt.wantFileName)
+				}
+				if got := h.Header.Get("Content-Type"); got != tt.wantContentType {
+					t.Errorf("content type = %v, want %v", got, tt.wantContentType)
+				}
+				fmt.Fprint(w, `{"returnCode":1,"data":{"personID":"1"}}`)
+			})
+			c.FacePreprocessor = tt.preprocessor
+
+			_, err := c.Persons.Register(context.Background(), PersonRegisterRequest{
+				PersonFaceUpdateRequest: &PersonFaceUpdateRequest{
+					AliasID: "1",
+					PlaceID: 1,
+					File:    bytes.NewReader(tt.data),
+				},
+			})
+			if err != nil {
+				t.Errorf("PersonService.Register() error = %v", err)
+			}
+		})
+	}
+}
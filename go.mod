@@ -4,7 +4,9 @@ go 1.16
 
 require (
 	github.com/alecthomas/kong v0.6.1
+	github.com/eclipse/paho.mqtt.golang v1.3.5
 	github.com/google/go-querystring v1.0.0
 	go.opencensus.io v0.23.0
 	golang.org/x/oauth2 v0.0.0-20220808172628-8227340efae7
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -9,6 +9,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"strings"
 	"time"
@@ -32,12 +33,34 @@ type Client struct {
 	// User agent used when communicating with the Hanet AI API.
 	UserAgent string
 
+	// RetryPolicy is applied to the requests when set. Requests are sent
+	// once when it is nil. The endpoints creating resources, such as
+	// person/register, are only retried when set in RetryOverrides, as a
+	// retry after a lost response fails.
+	RetryPolicy *RetryPolicy
+
+	// RetryOverrides replaces RetryPolicy for the given endpoints, keyed by
+	// the path relative to BaseURL (e.g. "person/register"). A nil policy
+	// disables retrying for that endpoint.
+	RetryOverrides map[string]*RetryPolicy
+
+	// FacePreprocessor prepares the face photos uploaded by PersonService
+	// Register and UpdateByFaceImage. Photos are uploaded as is when it is
+	// nil.
+	FacePreprocessor *FacePreprocessor
+
+	// Logger receives the log of each attempt of the requests, with the
+	// names of the parameters but not their values. Nothing is logged when
+	// it is nil.
+	Logger RequestLogger
+
 	common service // Reuse a single struct instead of allocating one for each service on the heap.
 
-	Devices *DeviceService
-	Persons *PersonService
-	Places  *PlaceService
-	Profile *ProfileService
+	Departments *DepartmentService
+	Devices     *DeviceService
+	Persons     *PersonService
+	Places      *PlaceService
+	Profile     *ProfileService
 }
 
 type service struct {
@@ -70,6 +93,7 @@ func NewClient(httpClient HttpClient, ts oauth2.TokenSource) *Client {
 
 	c.common.client = c
 
+	c.Departments = (*DepartmentService)(&c.common)
 	c.Devices = (*DeviceService)(&c.common)
 	c.Persons = (*PersonService)(&c.common)
 	c.Places = (*PlaceService)(&c.common)
@@ -94,7 +118,26 @@ func urlencodeBody(body interface{}) requestBodyFn {
 }
 
 func multipartBody(file io.Reader, fn func(m *multipart.Writer) error) requestBodyFn {
+	return faceBody(file, nil, fn)
+}
+
+// faceBody is a multipart body with a face photo, prepared by p. The photo
+// is read and prepared once and kept in memory, so the body can be built
+// again without sending an empty file.
+func faceBody(file io.Reader, p *FacePreprocessor, fn func(m *multipart.Writer) error) requestBodyFn {
+	var img *FaceImage
 	return func(token string) (io.Reader, string, error) {
+		if file != nil && img == nil {
+			b, err := io.ReadAll(file)
+			if err != nil {
+				return nil, "", err
+			}
+			img, err = p.prepare(b)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+
 		body := bytes.NewBuffer(nil)
 
 		w := multipart.NewWriter(body)
@@ -105,13 +148,16 @@ func multipartBody(file io.Reader, fn func(m *multipart.Writer) error) requestBo
 
 		w.WriteField("token", token)
 
-		if file != nil {
-			f, err := w.CreateFormFile("file", fileName)
+		if img != nil {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, img.FileName))
+			h.Set("Content-Type", img.ContentType)
+			f, err := w.CreatePart(h)
 			if err != nil {
 				return nil, "", err
 			}
 
-			_, err = io.Copy(f, file)
+			_, err = f.Write(img.Data)
 			if err != nil {
 				return nil, "", err
 			}
@@ -168,11 +214,63 @@ func (c *Client) NewRequest(urlStr string, fn requestBodyFn) (*http.Request, err
 //
 // The provided ctx must be non-nil, if it is nil an error is returned. If it
 // is canceled or times out, ctx.Err() will be returned.
+//
+// Failed requests are retried according to the RetryPolicy of the Client,
+// as long as the request body can be rebuilt and ctx allows it.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
 	if ctx == nil {
 		return nil, errors.New("context must be non-nil")
 	}
 
+	p := c.retryPolicy(req)
+	attempts := p.maxAttempts()
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, req, v, attempt)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !p.retriable(err) {
+			return resp, err
+		}
+
+		if !sleep(ctx, p.backoff(attempt)) {
+			return resp, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}, attempt int) (*http.Response, error) {
+	if c.Logger == nil {
+		return c.send(ctx, req, v, nil)
+	}
+
+	l := &RequestLog{
+		Endpoint:    strings.TrimPrefix(req.URL.Path, c.BaseURL.Path),
+		Attempt:     attempt,
+		RequestSize: req.ContentLength,
+		Params:      requestParams(req),
+	}
+	start := time.Now()
+	resp, err := c.send(ctx, req, v, l)
+	l.Latency = time.Since(start)
+	l.Err = err
+	c.Logger.LogRequest(ctx, l)
+
+	return resp, err
+}
+
+// send sends the request once, filling in the response fields of l when it
+// is not nil.
+func (c *Client) send(ctx context.Context, req *http.Request, v interface{}, l *RequestLog) (*http.Response, error) {
 	req = req.WithContext(ctx)
 
 	resp, err := c.client.Do(req)
@@ -181,8 +279,22 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 	}
 	defer resp.Body.Close()
 
+	if l != nil {
+		l.StatusCode = resp.StatusCode
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return resp, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body := &countingReader{r: resp.Body}
 	var env envelope
-	err = json.NewDecoder(resp.Body).Decode(&env)
+	err = json.NewDecoder(body).Decode(&env)
+	if l != nil {
+		l.ResponseSize = body.n
+		l.ReturnCode = env.ReturnCode
+		l.ReturnMessage = env.ReturnMessage
+	}
 	if err != nil {
 		return resp, err
 	}
@@ -0,0 +1,56 @@
+package hanetai
+
+import (
+	"net/url"
+	"testing"
+
+	"giautm.dev/hanetai/hanettest"
+	"golang.org/x/oauth2"
+)
+
+const testToken = "test-token"
+
+var ts = oauth2.StaticTokenSource(&oauth2.Token{
+	AccessToken: testToken,
+})
+
+// newTestClient returns a client talking to a fake server, seeded with the
+// place 1542, the device C21024B155 and two persons of the place.
+func newTestClient(t *testing.T) (*Client, *hanettest.Server) {
+	srv := hanettest.NewServer()
+	srv.Token = testToken
+	t.Cleanup(srv.Close)
+
+	srv.AddPlace(hanettest.Place{
+		ID:      1542,
+		Name:    "Hanet HQ",
+		Address: "Hà Nội",
+	})
+	srv.AddDevice(hanettest.Device{
+		ID:      "C21024B155",
+		Name:    "Front door",
+		Address: "Hà Nội",
+		PlaceID: 1542,
+		Online:  true,
+	})
+	srv.AddPerson(hanettest.Person{
+		PersonID: "1858497629510868990",
+		AliasID:  "VCFL1231231",
+		PlaceID:  1542,
+		Name:     "Nguyễn Văn A",
+		Title:    "Nhân viên",
+		Face:     "face-a",
+	})
+	srv.AddPerson(hanettest.Person{
+		PersonID: "1858497629510868992",
+		AliasID:  "852576",
+		PlaceID:  1542,
+		Name:     "Trần Thị B",
+		Title:    "Nhân viên",
+		Face:     "face-b",
+	})
+
+	c := NewClient(srv.Client(), ts)
+	c.BaseURL, _ = url.Parse(srv.URL + "/")
+	return c, srv
+}
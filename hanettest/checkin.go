@@ -0,0 +1,134 @@
+package hanettest
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Checkin is a recognition stored by the fake server.
+type Checkin struct {
+	PersonID   string
+	AliasID    string
+	PersonName string
+	PersonType int
+	Title      string
+	Avatar     string
+
+	PlaceID    int
+	DeviceID   string
+	DeviceName string
+
+	Time time.Time
+}
+
+type checkinItem struct {
+	PersonID    string `json:"personID"`
+	AliasID     string `json:"aliasID"`
+	PersonName  string `json:"personName"`
+	Type        int    `json:"type"`
+	Title       string `json:"title"`
+	Avatar      string `json:"avatar"`
+	PlaceID     int    `json:"placeID"`
+	PlaceName   string `json:"placeName"`
+	DeviceID    string `json:"deviceID"`
+	DeviceName  string `json:"deviceName"`
+	CheckinTime int64  `json:"checkinTime"`
+	Date        string `json:"date"`
+}
+
+// AddCheckin stores a check-in, returned by the check-in history APIs.
+func (s *Server) AddCheckin(c Checkin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkins = append(s.checkins, c)
+	sort.SliceStable(s.checkins, func(i, j int) bool {
+		return s.checkins[i].Time.Before(s.checkins[j].Time)
+	})
+}
+
+func (s *Server) checkinItem(c Checkin) checkinItem {
+	i := checkinItem{
+		PersonID:    c.PersonID,
+		AliasID:     c.AliasID,
+		PersonName:  c.PersonName,
+		Type:        c.PersonType,
+		Title:       c.Title,
+		Avatar:      c.Avatar,
+		PlaceID:     c.PlaceID,
+		DeviceID:    c.DeviceID,
+		DeviceName:  c.DeviceName,
+		CheckinTime: c.Time.UnixNano() / int64(time.Millisecond),
+		Date:        c.Time.Format("2006-01-02 15:04:05"),
+	}
+	if p, ok := s.places[c.PlaceID]; ok {
+		i.PlaceName = p.Name
+	}
+	return i
+}
+
+func (s *Server) listCheckins(r *request, match func(Checkin) bool) (interface{}, error) {
+	placeID, err := r.num("placeID")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.places[placeID]; !ok {
+		return nil, errorf(CodeNotFound, "Place not found")
+	}
+
+	from, err := strconv.ParseInt(r.str("from"), 10, 64)
+	if err != nil {
+		return nil, errorf(CodeInvalidParams, "from is invalid")
+	}
+	to, err := strconv.ParseInt(r.str("to"), 10, 64)
+	if err != nil {
+		return nil, errorf(CodeInvalidParams, "to is invalid")
+	}
+	page, err := r.num("page")
+	if err != nil {
+		return nil, err
+	}
+	size, err := r.num("size")
+	if err != nil {
+		return nil, err
+	}
+
+	items := []checkinItem{}
+	for _, c := range s.checkins {
+		ts := c.Time.UnixNano() / int64(time.Millisecond)
+		if c.PlaceID != placeID || ts < from || ts > to || !match(c) {
+			continue
+		}
+		items = append(items, s.checkinItem(c))
+	}
+
+	start, end := pageBounds(len(items), page, size)
+	return items[start:end], nil
+}
+
+func (s *Server) getCheckinByPlace(r *request) (interface{}, error) {
+	devices := map[string]bool{}
+	for _, id := range r.list("devices") {
+		devices[id] = true
+	}
+	personType := r.str("type")
+
+	return s.listCheckins(r, func(c Checkin) bool {
+		if len(devices) > 0 && !devices[c.DeviceID] {
+			return false
+		}
+		return personType == "" || strconv.Itoa(c.PersonType) == personType
+	})
+}
+
+func (s *Server) getCheckinByAliasID(r *request) (interface{}, error) {
+	aliasID := r.str("aliasID")
+	if aliasID == "" {
+		return nil, errorf(CodeInvalidParams, "aliasID is required")
+	}
+
+	return s.listCheckins(r, func(c Checkin) bool {
+		return c.AliasID == aliasID
+	})
+}
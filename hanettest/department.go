@@ -0,0 +1,164 @@
+package hanettest
+
+import (
+	"sort"
+)
+
+type Department struct {
+	ID      int    `json:"id"`
+	PlaceID int    `json:"placeID"`
+	Name    string `json:"name"`
+	Desc    string `json:"desc"`
+}
+
+// AddDepartment stores a department, a new ID is assigned when d.ID is zero.
+func (s *Server) AddDepartment(d Department) Department {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d.ID == 0 {
+		d.ID = s.newID()
+	}
+	s.departments[d.ID] = &d
+	return d
+}
+
+// Departments returns a snapshot of the departments of the place, or of
+// every department when placeID is zero.
+func (s *Server) Departments(placeID int) []Department {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedDepartments(placeID)
+}
+
+func (s *Server) sortedDepartments(placeID int) []Department {
+	items := []Department{}
+	for _, d := range s.departments {
+		if placeID == 0 || d.PlaceID == placeID {
+			items = append(items, *d)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func (s *Server) department(r *request) (*Department, error) {
+	id, err := r.num("id")
+	if err != nil {
+		return nil, err
+	}
+	d, ok := s.departments[id]
+	if !ok {
+		return nil, errorf(CodeNotFound, "Department not found")
+	}
+	return d, nil
+}
+
+func (s *Server) addDepartment(r *request) (interface{}, error) {
+	if _, err := s.place(r); err != nil {
+		return nil, err
+	}
+	if r.str("name") == "" {
+		return nil, errorf(CodeInvalidParams, "name is required")
+	}
+
+	placeID, _ := r.num("placeID")
+	d := &Department{
+		ID:      s.newID(),
+		PlaceID: placeID,
+		Name:    r.str("name"),
+		Desc:    r.str("desc"),
+	}
+	s.departments[d.ID] = d
+	return d, nil
+}
+
+func (s *Server) updateDepartment(r *request) (interface{}, error) {
+	d, err := s.department(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := r.str("name"); v != "" {
+		d.Name = v
+	}
+	if r.has("desc") {
+		d.Desc = r.str("desc")
+	}
+	return nil, nil
+}
+
+func (s *Server) removeDepartment(r *request) (interface{}, error) {
+	d, err := s.department(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range s.persons {
+		if p.DepartmentID == d.ID {
+			p.DepartmentID = 0
+		}
+	}
+	delete(s.departments, d.ID)
+	return nil, nil
+}
+
+func (s *Server) listDepartment(r *request) (interface{}, error) {
+	p, err := s.place(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.sortedDepartments(p.ID), nil
+}
+
+// setDepartment assigns the listed persons to the department, or removes
+// them from it when unset is true.
+func (s *Server) setDepartment(r *request, unset bool) (interface{}, error) {
+	d, err := s.department(r)
+	if err != nil {
+		return nil, err
+	}
+
+	aliasIDs := r.list("aliasIDs")
+	if len(aliasIDs) == 0 {
+		return nil, errorf(CodeInvalidParams, "aliasIDs is required")
+	}
+	for _, aliasID := range aliasIDs {
+		p := s.findPerson(d.PlaceID, aliasID)
+		if p == nil {
+			return nil, errorf(CodeNotFound, "Person not found")
+		}
+		if !unset {
+			p.DepartmentID = d.ID
+		} else if p.DepartmentID == d.ID {
+			p.DepartmentID = 0
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) addDepartmentPerson(r *request) (interface{}, error) {
+	return s.setDepartment(r, false)
+}
+
+func (s *Server) removeDepartmentPerson(r *request) (interface{}, error) {
+	return s.setDepartment(r, true)
+}
+
+func (s *Server) listDepartmentPerson(r *request) (interface{}, error) {
+	d, err := s.department(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []personItem{}
+	for _, p := range s.persons {
+		if p.DepartmentID == d.ID {
+			items = append(items, p.item())
+		}
+	}
+	return items, nil
+}
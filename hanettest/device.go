@@ -0,0 +1,111 @@
+package hanettest
+
+import (
+	"sort"
+)
+
+type deviceInfo struct {
+	DeviceID   string `json:"deviceID"`
+	DeviceName string `json:"deviceName"`
+	Address    string `json:"address"`
+
+	PlaceID   int    `json:"placeID"`
+	PlaceName string `json:"placeName"`
+}
+
+func (s *Server) sortedDevices() []*Device {
+	items := make([]*Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		items = append(items, d)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func (s *Server) device(r *request) (*Device, error) {
+	d, ok := s.devices[r.str("deviceID")]
+	if !ok {
+		return nil, errorf(CodeNotFound, "Device not found")
+	}
+	return d, nil
+}
+
+func (s *Server) deviceInfos(placeID int) []deviceInfo {
+	items := []deviceInfo{}
+	for _, d := range s.sortedDevices() {
+		if placeID != 0 && d.PlaceID != placeID {
+			continue
+		}
+
+		i := deviceInfo{
+			DeviceID:   d.ID,
+			DeviceName: d.Name,
+			Address:    d.Address,
+			PlaceID:    d.PlaceID,
+		}
+		if p, ok := s.places[d.PlaceID]; ok {
+			i.PlaceName = p.Name
+		}
+		items = append(items, i)
+	}
+	return items
+}
+
+func (s *Server) getConnectionStatus(r *request) (interface{}, error) {
+	ids := r.list("deviceIDs")
+	if len(ids) == 0 {
+		return nil, errorf(CodeInvalidParams, "deviceIDs is required")
+	}
+
+	status := map[string]bool{}
+	for _, id := range ids {
+		d, ok := s.devices[id]
+		if !ok {
+			return nil, errorf(CodeNotFound, "Device not found")
+		}
+		status[id] = d.Online
+	}
+	return status, nil
+}
+
+func (s *Server) getListDevice(r *request) (interface{}, error) {
+	return s.deviceInfos(0), nil
+}
+
+func (s *Server) getListDeviceByPlace(r *request) (interface{}, error) {
+	p, err := s.place(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.deviceInfos(p.ID), nil
+}
+
+func (s *Server) setDeviceMQTT(r *request) (interface{}, error) {
+	d, err := s.device(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d.MQTT = &MQTTConfig{
+		Enable:   r.str("enable") == "1",
+		URL:      r.str("url"),
+		Username: r.str("username"),
+		Password: r.str("password"),
+		Image:    r.str("image") == "1",
+	}
+	return nil, nil
+}
+
+func (s *Server) updateDevice(r *request) (interface{}, error) {
+	d, err := s.device(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := r.str("deviceName"); v != "" {
+		d.Name = v
+	}
+	return nil, nil
+}
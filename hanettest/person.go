@@ -0,0 +1,336 @@
+package hanettest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+type personItem struct {
+	Name     string `json:"name"`
+	AliasID  string `json:"aliasID"`
+	PersonID string `json:"personID"`
+	Title    string `json:"title"`
+	Avatar   string `json:"avatar"`
+}
+
+type personItemWithPlace struct {
+	personItem
+	PlaceID int `json:"placeID"`
+}
+
+type registerResponse struct {
+	Name    string `json:"name"`
+	AliasID string `json:"aliasID"`
+	PlaceID int    `json:"placeID"`
+	Title   string `json:"title"`
+	Type    int    `json:"type"`
+
+	PersonID string `json:"personID,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+func (p *Person) item() personItem {
+	return personItem{
+		Name:     p.Name,
+		AliasID:  p.AliasID,
+		PersonID: p.PersonID,
+		Title:    p.Title,
+		Avatar:   p.Avatar,
+	}
+}
+
+func (p *Person) registerResponse() registerResponse {
+	return registerResponse{
+		Name:     p.Name,
+		AliasID:  p.AliasID,
+		PlaceID:  p.PlaceID,
+		Title:    p.Title,
+		Type:     p.Type,
+		PersonID: p.PersonID,
+		File:     p.Avatar,
+	}
+}
+
+// face returns the identity of the uploaded face, either the checksum of the
+// "file" part or the "url" field.
+func (s *Server) face(r *request) (string, error) {
+	if u := r.str("url"); u != "" {
+		return "url:" + u, nil
+	}
+
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		return "", errorf(CodeInvalidImage, "Image is required")
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", errorf(CodePersonImgInvalid, "Image is invalid")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *Server) findPerson(placeID int, aliasID string) *Person {
+	for _, p := range s.persons {
+		if p.PlaceID == placeID && p.AliasID == aliasID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) personByPlace(r *request) (*Person, error) {
+	placeID, err := r.num("placeID")
+	if err != nil {
+		return nil, err
+	}
+	p := s.findPerson(placeID, r.str("aliasID"))
+	if p == nil {
+		return nil, errorf(CodeNotFound, "Person not found")
+	}
+	return p, nil
+}
+
+// checkDuplicatedFace rejects a face already registered by another person
+// of the place, the other person is returned as data of the error.
+func (s *Server) checkDuplicatedFace(placeID int, face string, self *Person) error {
+	for _, p := range s.persons {
+		if p != self && p.PlaceID == placeID && p.Face == face {
+			return &apiError{
+				Code:    CodeDuplicatedImage,
+				Message: "Image is duplicated",
+				Data:    p.registerResponse(),
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) removeWhere(fn func(*Person) bool) int {
+	n := 0
+	persons := s.persons[:0]
+	for _, p := range s.persons {
+		if fn(p) {
+			n++
+			continue
+		}
+		persons = append(persons, p)
+	}
+	s.persons = persons
+	return n
+}
+
+func (s *Server) register(r *request) (interface{}, error) {
+	placeID, err := r.num("placeID")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.places[placeID]; !ok {
+		return nil, errorf(CodeNotFound, "Place not found")
+	}
+	aliasID := r.str("aliasID")
+	if r.str("name") == "" || aliasID == "" {
+		return nil, errorf(CodeInvalidParams, "name and aliasID are required")
+	}
+	if s.findPerson(placeID, aliasID) != nil {
+		return nil, errorf(CodeEmployeeIsExists, "Employee is exists")
+	}
+
+	face, err := s.face(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicatedFace(placeID, face, nil); err != nil {
+		return nil, err
+	}
+
+	personType, err := r.num("type")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Person{
+		PersonID: strconv.Itoa(s.newID()),
+		AliasID:  aliasID,
+		PlaceID:  placeID,
+		Name:     r.str("name"),
+		Title:    r.str("title"),
+		Type:     personType,
+		Face:     face,
+	}
+	p.Avatar = s.URL + "/avatars/" + p.PersonID + ".jpg"
+	s.persons = append(s.persons, p)
+
+	return p.registerResponse(), nil
+}
+
+func (s *Server) updateByFace(r *request) (interface{}, error) {
+	p, err := s.personByPlace(r)
+	if err != nil {
+		return nil, err
+	}
+
+	face, err := s.face(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicatedFace(p.PlaceID, face, p); err != nil {
+		return nil, err
+	}
+
+	p.Face = face
+	return p.registerResponse(), nil
+}
+
+func (s *Server) updatePerson(r *request) (interface{}, error) {
+	p, err := s.personByPlace(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(r.str("updates")), &updates); err != nil {
+		return nil, errorf(CodeInvalidParams, "updates is invalid")
+	}
+
+	if updates.Name != "" {
+		p.Name = updates.Name
+	}
+	if updates.Title != "" {
+		p.Title = updates.Title
+	}
+	return nil, nil
+}
+
+func (s *Server) updateAliasID(r *request) (interface{}, error) {
+	// NOTE: persionID is typo from Hanet
+	personID := r.str("persionID")
+	for _, p := range s.persons {
+		if p.PersonID == personID {
+			p.AliasID = r.str("aliasID")
+			return nil, nil
+		}
+	}
+	return nil, errorf(CodeNotFound, "Person not found")
+}
+
+func (s *Server) removePerson(r *request) (interface{}, error) {
+	aliasID := r.str("aliasID")
+	if n := s.removeWhere(func(p *Person) bool {
+		return p.AliasID == aliasID
+	}); n == 0 {
+		return nil, errorf(CodeNotFound, "Person not found")
+	}
+	return nil, nil
+}
+
+func (s *Server) removePersonByPlace(r *request) (interface{}, error) {
+	p, err := s.personByPlace(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.removeWhere(func(o *Person) bool {
+		return o == p
+	})
+	return nil, nil
+}
+
+func (s *Server) removePersonByID(r *request) (interface{}, error) {
+	personID := r.str("personID")
+	if n := s.removeWhere(func(p *Person) bool {
+		return p.PersonID == personID
+	}); n == 0 {
+		return nil, errorf(CodeNotFound, "Person not found")
+	}
+	return nil, nil
+}
+
+func (s *Server) removePersonByListAliasID(r *request) (interface{}, error) {
+	aliasIDs := map[string]bool{}
+	for _, id := range r.list("aliasIDs") {
+		aliasIDs[id] = true
+	}
+	placeIDs := map[int]bool{}
+	for _, id := range r.list("placeIDs") {
+		i, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, errorf(CodeInvalidParams, "placeIDs is invalid")
+		}
+		placeIDs[i] = true
+	}
+
+	s.removeWhere(func(p *Person) bool {
+		return aliasIDs[p.AliasID] && (len(placeIDs) == 0 || placeIDs[p.PlaceID])
+	})
+	return nil, nil
+}
+
+func (s *Server) getListByPlace(r *request) (interface{}, error) {
+	placeID, err := r.num("placeID")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.places[placeID]; !ok {
+		return nil, errorf(CodeNotFound, "Place not found")
+	}
+	page, err := r.num("page")
+	if err != nil {
+		return nil, err
+	}
+	size, err := r.num("size")
+	if err != nil {
+		return nil, err
+	}
+	personType := r.str("type")
+
+	items := []personItem{}
+	for _, p := range s.persons {
+		if p.PlaceID != placeID {
+			continue
+		}
+		if personType != "" && strconv.Itoa(p.Type) != personType {
+			continue
+		}
+		items = append(items, p.item())
+	}
+
+	start, end := pageBounds(len(items), page, size)
+	return items[start:end], nil
+}
+
+func (s *Server) getListByAliasIDAllPlace(r *request) (interface{}, error) {
+	aliasID := r.str("aliasID")
+	if aliasID == "" {
+		return nil, errorf(CodeInvalidParams, "aliasID is required")
+	}
+
+	items := []personItemWithPlace{}
+	for _, p := range s.persons {
+		if p.AliasID == aliasID {
+			items = append(items, personItemWithPlace{
+				personItem: p.item(),
+				PlaceID:    p.PlaceID,
+			})
+		}
+	}
+	return items, nil
+}
+
+func (s *Server) takeFacePicture(r *request) (interface{}, error) {
+	if _, err := s.device(r); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
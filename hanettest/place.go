@@ -0,0 +1,77 @@
+package hanettest
+
+import (
+	"sort"
+)
+
+func (s *Server) sortedPlaces() []Place {
+	items := make([]Place, 0, len(s.places))
+	for _, p := range s.places {
+		items = append(items, *p)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func (s *Server) place(r *request) (*Place, error) {
+	id, err := r.num("placeID")
+	if err != nil {
+		return nil, err
+	}
+	p, ok := s.places[id]
+	if !ok {
+		return nil, errorf(CodeNotFound, "Place not found")
+	}
+	return p, nil
+}
+
+func (s *Server) addPlace(r *request) (interface{}, error) {
+	if r.str("name") == "" {
+		return nil, errorf(CodeInvalidParams, "name is required")
+	}
+
+	p := &Place{
+		ID:      s.newID(),
+		Name:    r.str("name"),
+		Address: r.str("address"),
+	}
+	s.places[p.ID] = p
+	return p, nil
+}
+
+func (s *Server) updatePlace(r *request) (interface{}, error) {
+	p, err := s.place(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := r.str("name"); v != "" {
+		p.Name = v
+	}
+	if v := r.str("address"); v != "" {
+		p.Address = v
+	}
+	return nil, nil
+}
+
+func (s *Server) getPlaces(r *request) (interface{}, error) {
+	return s.sortedPlaces(), nil
+}
+
+func (s *Server) removePlace(r *request) (interface{}, error) {
+	p, err := s.place(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range s.devices {
+		if d.PlaceID == p.ID {
+			return nil, errorf(CodeInvalidParams, "Place still has devices")
+		}
+	}
+
+	delete(s.places, p.ID)
+	return nil, nil
+}
@@ -0,0 +1,5 @@
+package hanettest
+
+func (s *Server) getProfile(r *request) (interface{}, error) {
+	return s.profile, nil
+}
@@ -0,0 +1,438 @@
+// Package hanettest provides an in-process fake of the Hanet partner API,
+// for testing code built on top of the hanetai package without a live token.
+//
+// The fake keeps places, devices and persons in memory and answers with the
+// same envelope as partner.hanet.ai:
+//
+//	srv := hanettest.NewServer()
+//	defer srv.Close()
+//
+//	c := hanetai.NewClient(srv.Client(), ts)
+//	c.BaseURL, _ = url.Parse(srv.URL + "/")
+package hanettest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Return codes used by the fake, they mirror the codes of the partner API.
+const (
+	CodeSuccess          = 1
+	CodeInvalidParams    = -1
+	CodeInvalidToken     = -2
+	CodeNotFound         = -3
+	CodeUnsupported      = -404
+	CodePersonImgInvalid = -5010
+	CodeEmployeeIsExists = -9005
+	CodeInvalidImage     = -9006
+	CodeDuplicatedImage  = -9007
+)
+
+// Failure describes an error injected in front of an endpoint.
+type Failure struct {
+	// Code and Message are returned in the envelope.
+	Code    int
+	Message string
+
+	// StatusCode is the HTTP status of the response, 200 when zero. A non-2xx
+	// status is written without a JSON body.
+	StatusCode int
+
+	// Times is the number of requests that fail before the endpoint recovers.
+	// Zero means every request fails until ClearFailures is called.
+	Times int
+}
+
+type Place struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type Device struct {
+	ID      string
+	Name    string
+	Address string
+	PlaceID int
+	Online  bool
+
+	MQTT *MQTTConfig
+}
+
+type MQTTConfig struct {
+	Enable   bool
+	URL      string
+	Username string
+	Password string
+	Image    bool
+}
+
+type Person struct {
+	PersonID string
+	AliasID  string
+	PlaceID  int
+	Name     string
+	Title    string
+	Type     int
+	Avatar   string
+
+	DepartmentID int
+
+	// Face identifies the registered face image, persons with the same face
+	// in a place are rejected with CodeDuplicatedImage.
+	Face string
+}
+
+type Profile struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Server is a fake Hanet partner API.
+type Server struct {
+	*httptest.Server
+
+	// Token, when set, is the only access token accepted by the server.
+	Token string
+
+	mu          sync.Mutex
+	nextID      int
+	profile     Profile
+	places      map[int]*Place
+	departments map[int]*Department
+	devices     map[string]*Device
+	persons     []*Person
+	checkins    []Checkin
+	failures    map[string]*Failure
+	calls       map[string]int
+
+	routes map[string]func(*request) (interface{}, error)
+}
+
+// NewServer starts and returns a new fake server, callers should call Close
+// when finished to shut it down.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new fake server but doesn't start it.
+func NewUnstartedServer() *Server {
+	s := &Server{
+		nextID: 1000,
+		profile: Profile{
+			ID:    1,
+			Name:  "Hanet Test",
+			Email: "test@hanet.ai",
+		},
+		places:      map[int]*Place{},
+		departments: map[int]*Department{},
+		devices:     map[string]*Device{},
+		failures:    map[string]*Failure{},
+		calls:       map[string]int{},
+	}
+	s.routes = map[string]func(*request) (interface{}, error){
+		"department/add":          s.addDepartment,
+		"department/addPerson":    s.addDepartmentPerson,
+		"department/list":         s.listDepartment,
+		"department/listPerson":   s.listDepartmentPerson,
+		"department/remove":       s.removeDepartment,
+		"department/removePerson": s.removeDepartmentPerson,
+		"department/update":       s.updateDepartment,
+
+		"device/getConnectionStatus":  s.getConnectionStatus,
+		"device/getListDevice":        s.getListDevice,
+		"device/getListDeviceByPlace": s.getListDeviceByPlace,
+		"device/setDeviceMQTT":        s.setDeviceMQTT,
+		"device/updateDevice":         s.updateDevice,
+
+		"person/getCheckinByPersonByPlaceIdInTimestamp": s.getCheckinByAliasID,
+		"person/getCheckinByPlaceIdInTimestamp":         s.getCheckinByPlace,
+		"person/getListByAliasIDAllPlace":               s.getListByAliasIDAllPlace,
+		"person/getListByPlace":                         s.getListByPlace,
+		"person/getUserInfoByAliasID":                   s.getListByAliasIDAllPlace,
+		"person/register":                               s.register,
+		"person/registerByUrl":                          s.register,
+		"person/remove":                                 s.removePerson,
+		"person/removeByPlace":                          s.removePersonByPlace,
+		"person/removePersonByID":                       s.removePersonByID,
+		"person/removePersonByListAliasID":              s.removePersonByListAliasID,
+		"person/takeFacePicture":                        s.takeFacePicture,
+		"person/update":                                 s.updatePerson,
+		"person/updateAliasID":                          s.updateAliasID,
+		"person/updateByFaceImage":                      s.updateByFace,
+		"person/updateByFaceUrl":                        s.updateByFace,
+
+		"place/addPlace":    s.addPlace,
+		"place/getPlaces":   s.getPlaces,
+		"place/removePlace": s.removePlace,
+		"place/updatePlace": s.updatePlace,
+
+		"profile/getProfile": s.getProfile,
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// SetProfile replaces the profile returned by profile/getProfile.
+func (s *Server) SetProfile(p Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profile = p
+}
+
+// AddPlace stores a place, a new ID is assigned when p.ID is zero.
+func (s *Server) AddPlace(p Place) Place {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.ID == 0 {
+		p.ID = s.newID()
+	}
+	s.places[p.ID] = &p
+	return p
+}
+
+// AddDevice stores a device, replacing any device with the same ID.
+func (s *Server) AddDevice(d Device) Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.devices[d.ID] = &d
+	return d
+}
+
+// AddPerson stores a person, a new PersonID is assigned when it is empty.
+func (s *Server) AddPerson(p Person) Person {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.PersonID == "" {
+		p.PersonID = strconv.Itoa(s.newID())
+	}
+	s.persons = append(s.persons, &p)
+	return p
+}
+
+// Places returns a snapshot of the stored places.
+func (s *Server) Places() []Place {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedPlaces()
+}
+
+// Devices returns a snapshot of the stored devices.
+func (s *Server) Devices() []Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]Device, 0, len(s.devices))
+	for _, d := range s.sortedDevices() {
+		items = append(items, *d)
+	}
+	return items
+}
+
+// Persons returns a snapshot of the persons registered at the place, or of
+// every person when placeID is zero.
+func (s *Server) Persons(placeID int) []Person {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []Person
+	for _, p := range s.persons {
+		if placeID == 0 || p.PlaceID == placeID {
+			items = append(items, *p)
+		}
+	}
+	return items
+}
+
+// Fail injects a failure in front of the endpoint, e.g. "person/register".
+func (s *Server) Fail(endpoint string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[endpoint] = &f
+}
+
+// ClearFailures removes every injected failure.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = map[string]*Failure{}
+}
+
+// Calls returns the number of requests received by the endpoint.
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[endpoint]
+}
+
+type envelope struct {
+	StatusCode    int         `json:"statusCode"`
+	ReturnCode    int         `json:"returnCode"`
+	ReturnMessage string      `json:"returnMessage"`
+	Data          interface{} `json:"data,omitempty"`
+}
+
+// apiError is returned by the handlers to answer with an error envelope,
+// Data is sent along with the error when set.
+type apiError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func errorf(code int, message string) error {
+	return &apiError{Code: code, Message: message}
+}
+
+type request struct {
+	*http.Request
+}
+
+func (r *request) str(key string) string {
+	return r.FormValue(key)
+}
+
+// has reports whether the key is set, even to an empty value.
+func (r *request) has(key string) bool {
+	r.FormValue(key)
+	_, ok := r.Form[key]
+	return ok
+}
+
+func (r *request) num(key string) (int, error) {
+	v := r.FormValue(key)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errorf(CodeInvalidParams, key+" is invalid")
+	}
+	return i, nil
+}
+
+func (r *request) list(key string) []string {
+	v := r.FormValue(key)
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/")
+
+	err := r.ParseMultipartForm(32 << 20)
+	if errors.Is(err, http.ErrNotMultipart) {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		writeEnvelope(w, errorf(CodeInvalidParams, err.Error()), nil)
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[endpoint]++
+	f := s.failures[endpoint]
+	if f != nil && f.Times > 0 {
+		if f.Times--; f.Times == 0 {
+			delete(s.failures, endpoint)
+		}
+	}
+	s.mu.Unlock()
+
+	if f != nil {
+		if f.StatusCode != 0 && f.StatusCode != http.StatusOK {
+			w.WriteHeader(f.StatusCode)
+			return
+		}
+		writeEnvelope(w, errorf(f.Code, f.Message), nil)
+		return
+	}
+
+	if s.Token != "" && r.FormValue("token") != s.Token {
+		writeEnvelope(w, errorf(CodeInvalidToken, "Invalid token"), nil)
+		return
+	}
+
+	route, ok := s.routes[endpoint]
+	if !ok {
+		writeEnvelope(w, errorf(CodeUnsupported, "Unsupported API"), nil)
+		return
+	}
+
+	s.mu.Lock()
+	data, err := route(&request{r})
+	s.mu.Unlock()
+
+	writeEnvelope(w, err, data)
+}
+
+func writeEnvelope(w http.ResponseWriter, err error, data interface{}) {
+	env := envelope{
+		StatusCode:    http.StatusOK,
+		ReturnCode:    CodeSuccess,
+		ReturnMessage: "Success",
+		Data:          data,
+	}
+	if err != nil {
+		var ae *apiError
+		if !errors.As(err, &ae) {
+			ae = &apiError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		env.ReturnCode = ae.Code
+		env.ReturnMessage = ae.Message
+		env.Data = ae.Data
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(env)
+}
+
+// pageBounds returns the slice bounds of a 1-based page, every item is
+// returned when size is not positive.
+func pageBounds(n, page, size int) (int, int) {
+	if size <= 0 {
+		return 0, n
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * size
+	if start > n {
+		start = n
+	}
+	end := start + size
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
@@ -0,0 +1,110 @@
+package hanettest_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"giautm.dev/hanetai"
+	"giautm.dev/hanetai/hanettest"
+	"golang.org/x/oauth2"
+)
+
+func newClient(t *testing.T) (*hanetai.Client, *hanettest.Server) {
+	srv := hanettest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c := hanetai.NewClient(srv.Client(), oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: "token",
+	}))
+	c.BaseURL, _ = url.Parse(srv.URL + "/")
+	return c, srv
+}
+
+func register(c *hanetai.Client, aliasID, face string) (*hanetai.PersonRegisterResponse, error) {
+	return c.Persons.Register(context.Background(), hanetai.PersonRegisterRequest{
+		PersonFaceUpdateRequest: &hanetai.PersonFaceUpdateRequest{
+			AliasID: aliasID,
+			PlaceID: 1,
+			File:    strings.NewReader(face),
+		},
+		Name: "Person " + aliasID,
+		Type: "0",
+	})
+}
+
+func TestServer_Register(t *testing.T) {
+	c, srv := newClient(t)
+	srv.AddPlace(hanettest.Place{ID: 1, Name: "Place"})
+
+	if _, err := register(c, "1", "face-1"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		aliasID  string
+		face     string
+		wantCode int
+	}{
+		{
+			name:     "employee is exists",
+			aliasID:  "1",
+			face:     "face-2",
+			wantCode: hanettest.CodeEmployeeIsExists,
+		},
+		{
+			name:     "duplicated image",
+			aliasID:  "2",
+			face:     "face-1",
+			wantCode: hanettest.CodeDuplicatedImage,
+		},
+		{
+			name:     "empty image",
+			aliasID:  "3",
+			face:     "",
+			wantCode: hanettest.CodePersonImgInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := register(c, tt.aliasID, tt.face)
+
+			var serr *hanetai.ServerError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Register() error = %v, want *ServerError", err)
+			}
+			if serr.Code != tt.wantCode {
+				t.Errorf("Register() code = %v, want %v", serr.Code, tt.wantCode)
+			}
+			if tt.wantCode == hanettest.CodeDuplicatedImage && (serr.Person == nil || serr.Person.AliasID != "1") {
+				t.Errorf("Register() duplicated person = %v, want alias %q", serr.Person, "1")
+			}
+		})
+	}
+
+	if got := len(srv.Persons(1)); got != 1 {
+		t.Errorf("Persons() = %v, want %v", got, 1)
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	c, srv := newClient(t)
+	srv.Fail("profile/getProfile", hanettest.Failure{
+		Code:    -1,
+		Message: "Internal error",
+		Times:   1,
+	})
+
+	if _, err := c.Profile.Me(context.Background()); err == nil {
+		t.Errorf("Me() error = nil, want injected failure")
+	}
+	if _, err := c.Profile.Me(context.Background()); err != nil {
+		t.Errorf("Me() error = %v, want recovered", err)
+	}
+	if got := srv.Calls("profile/getProfile"); got != 2 {
+		t.Errorf("Calls() = %v, want %v", got, 2)
+	}
+}
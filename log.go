@@ -0,0 +1,168 @@
+package hanetai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestLog describes an attempt of an API request, see Client.Logger.
+type RequestLog struct {
+	// Endpoint is the path relative to BaseURL, e.g. "person/register".
+	Endpoint string
+	Attempt  int
+	Latency  time.Duration
+
+	StatusCode    int
+	ReturnCode    int
+	ReturnMessage string
+
+	// RequestSize and ResponseSize are the sizes of the bodies, in bytes.
+	RequestSize  int64
+	ResponseSize int64
+
+	// Params are the names of the request parameters, sorted. Their values
+	// are not logged, as they hold the tokens and the personal data of the
+	// persons.
+	Params []string
+
+	Err error
+}
+
+// Attrs returns the fields of the log as alternating keys and values, as
+// taken by the log/slog functions:
+//
+//	c.Logger = hanetai.RequestLoggerFunc(func(ctx context.Context, l *hanetai.RequestLog) {
+//		slog.InfoContext(ctx, "hanet request", l.Attrs()...)
+//	})
+func (l *RequestLog) Attrs() []interface{} {
+	attrs := []interface{}{
+		"endpoint", l.Endpoint,
+		"attempt", l.Attempt,
+		"latency", l.Latency,
+		"statusCode", l.StatusCode,
+		"returnCode", l.ReturnCode,
+		"requestSize", l.RequestSize,
+		"responseSize", l.ResponseSize,
+	}
+	if l.ReturnMessage != "" {
+		attrs = append(attrs, "returnMessage", l.ReturnMessage)
+	}
+	if len(l.Params) > 0 {
+		attrs = append(attrs, "params", strings.Join(l.Params, ","))
+	}
+	if l.Err != nil {
+		attrs = append(attrs, "error", l.Err.Error())
+	}
+	return attrs
+}
+
+// String formats the log as key=value fields.
+func (l *RequestLog) String() string {
+	attrs := l.Attrs()
+	var b strings.Builder
+	for i := 0; i < len(attrs); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		v := fmt.Sprint(attrs[i+1])
+		if strings.ContainsAny(v, " \"=") || v == "" {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, "%s=%s", attrs[i], v)
+	}
+	return b.String()
+}
+
+// RequestLogger receives the log of each attempt of the API requests.
+type RequestLogger interface {
+	LogRequest(ctx context.Context, l *RequestLog)
+}
+
+// RequestLoggerFunc is an adapter to use a function as a RequestLogger.
+type RequestLoggerFunc func(ctx context.Context, l *RequestLog)
+
+func (f RequestLoggerFunc) LogRequest(ctx context.Context, l *RequestLog) {
+	f(ctx, l)
+}
+
+// StdLogger returns a RequestLogger printing a line of key=value fields per
+// request to l.
+func StdLogger(l *log.Logger) RequestLogger {
+	return RequestLoggerFunc(func(ctx context.Context, rl *RequestLog) {
+		l.Print("hanet request ", rl.String())
+	})
+}
+
+// requestParams returns the sorted names of the parameters of the body of
+// req, it reads a copy of the body so req can still be sent.
+func requestParams(req *http.Request) []string {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil
+		}
+		v, err := url.ParseQuery(string(b))
+		if err != nil {
+			return nil
+		}
+		for k := range v {
+			seen[k] = true
+		}
+	case "multipart/form-data":
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := r.NextPart()
+			if err != nil {
+				break
+			}
+			seen[p.FormName()] = true
+		}
+	default:
+		return nil
+	}
+
+	names := make([]string, 0, len(seen))
+	for k := range seen {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,113 @@
+package hanetai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Logger(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	var logs []*RequestLog
+	c.Logger = RequestLoggerFunc(func(ctx context.Context, l *RequestLog) {
+		logs = append(logs, l)
+	})
+
+	if _, err := c.Devices.GetListDevicesByPlace(context.Background(), &ListDevicesByPlaceRequest{PlaceID: 1542}); err != nil {
+		t.Fatal(err)
+	}
+	face := bytes.Repeat([]byte("face"), 100)
+	_, err := c.Persons.Register(context.Background(), PersonRegisterRequest{
+		PersonFaceUpdateRequest: &PersonFaceUpdateRequest{AliasID: "VCFL1", PlaceID: 1542, File: bytes.NewReader(face)},
+		Name:                    "Nguyễn Văn B",
+		Type:                    "0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Devices.GetListDevicesByPlace(context.Background(), &ListDevicesByPlaceRequest{PlaceID: 1}); err == nil {
+		t.Fatal("GetListDevicesByPlace() error = nil, want an error for an unknown place")
+	}
+
+	if len(logs) != 3 {
+		t.Fatalf("logged %d requests, want 3", len(logs))
+	}
+	for _, l := range logs {
+		if l.Attempt != 1 || l.StatusCode != http.StatusOK || l.RequestSize <= 0 || l.ResponseSize <= 0 || l.Latency <= 0 {
+			t.Errorf("log = %+v, want the attempt, status, sizes and latency", l)
+		}
+		if s := l.String(); strings.Contains(s, testToken) || strings.Contains(s, "faceface") || strings.Contains(s, "Nguy") {
+			t.Errorf("log %q leaks the token, the face or the name", s)
+		}
+	}
+
+	if l := logs[0]; l.Endpoint != "device/getListDeviceByPlace" || l.ReturnCode != 1 || !reflect.DeepEqual(l.Params, []string{"placeID", "token"}) {
+		t.Errorf("log = %+v", l)
+	}
+	if l := logs[1]; l.Endpoint != "person/register" || !reflect.DeepEqual(l.Params, []string{"aliasID", "file", "name", "placeID", "title", "token", "type"}) {
+		t.Errorf("log = %+v", l)
+	}
+	if l := logs[2]; l.ReturnCode == 1 || l.Err == nil {
+		t.Errorf("log = %+v, want the error", l)
+	}
+}
+
+func TestClient_Logger_Retry(t *testing.T) {
+	var calls int32
+	c := newHandlerTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"returnCode":1,"returnMessage":"Success","data":{}}`))
+	})
+	c.RetryPolicy = &RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}
+
+	var attempts []int
+	var failed []error
+	c.Logger = RequestLoggerFunc(func(ctx context.Context, l *RequestLog) {
+		attempts = append(attempts, l.Attempt)
+		failed = append(failed, l.Err)
+	})
+
+	req, err := c.NewRequest("profile/me", urlencodeBody(struct{}{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(context.Background(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 || failed[0] == nil || failed[1] != nil {
+		t.Errorf("attempts = %v, errors = %v, want a failed then a successful attempt", attempts, failed)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := StdLogger(log.New(&buf, "", 0))
+	l.LogRequest(context.Background(), &RequestLog{
+		Endpoint:      "person/register",
+		Attempt:       1,
+		Latency:       120 * time.Millisecond,
+		StatusCode:    200,
+		ReturnCode:    -9004,
+		ReturnMessage: "Face not found",
+		RequestSize:   1024,
+		ResponseSize:  64,
+		Params:        []string{"aliasID", "file", "name"},
+		Err:           errors.New("boom"),
+	})
+
+	want := `hanet request endpoint=person/register attempt=1 latency=120ms statusCode=200 returnCode=-9004 requestSize=1024 responseSize=64 returnMessage="Face not found" params=aliasID,file,name error=boom` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("StdLogger() = %q, want %q", got, want)
+	}
+}
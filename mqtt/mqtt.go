@@ -0,0 +1,137 @@
+// Package mqtt handles the events that Hanet devices publish to an MQTT
+// broker, see DeviceService.SetDeviceMQTT, with the same webhook.Handler as
+// the HTTP webhooks.
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"giautm.dev/hanetai/webhook"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultTopic subscribes to all the topics of the broker.
+const DefaultTopic = "#"
+
+type Options struct {
+	OnError func(context.Context, error)
+	QoS     byte
+	Topic   string
+}
+
+type Option = func(*Options)
+
+// WithTopic subscribes to topic instead of DefaultTopic, it may contain
+// wildcards.
+func WithTopic(topic string) Option {
+	return func(o *Options) {
+		o.Topic = topic
+	}
+}
+
+func WithQoS(qos byte) Option {
+	return func(o *Options) {
+		o.QoS = qos
+	}
+}
+
+// WithOnError reports the messages that can't be decoded, and the errors of
+// the handler. MQTT has no way to ask for the message again, so they are
+// dropped after being reported.
+func WithOnError(fn func(context.Context, error)) Option {
+	return func(o *Options) {
+		o.OnError = fn
+	}
+}
+
+func newOptions(opts []Option) *Options {
+	o := &Options{
+		QoS:   1,
+		Topic: DefaultTopic,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Subscribe subscribes the client to the topic of the events, and handles
+// them with fn. Call it from the OnConnect handler of the client to
+// subscribe again after a reconnect.
+func Subscribe(c paho.Client, fn webhook.Handler, opts ...Option) error {
+	o := newOptions(opts)
+
+	t := c.Subscribe(o.Topic, o.QoS, messageHandler(fn, o))
+	t.Wait()
+	return t.Error()
+}
+
+// NewMessageHandler returns a paho.MessageHandler handling the events with
+// fn, for the clients subscribing on their own.
+func NewMessageHandler(fn webhook.Handler, opts ...Option) paho.MessageHandler {
+	return messageHandler(fn, newOptions(opts))
+}
+
+func messageHandler(fn webhook.Handler, o *Options) paho.MessageHandler {
+	return func(c paho.Client, m paho.Message) {
+		ctx := context.Background()
+
+		data, err := Decode(m.Payload())
+		if err != nil {
+			err = fmt.Errorf("mqtt: topic %s: %w", m.Topic(), err)
+		} else {
+			err = fn.ServeWebhook(ctx, data)
+		}
+		if err != nil && o.OnError != nil {
+			o.OnError(ctx, err)
+		}
+	}
+}
+
+type payload struct {
+	Image string `json:"image"`
+}
+
+// Decode decodes the payload of a message, the same as the body of a
+// webhook. The base64 image sent by the devices set up with Base64Image is
+// decoded into Data.Image.
+func Decode(b []byte) (*webhook.Data, error) {
+	var (
+		data webhook.Data
+		p    payload
+	)
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Image != "" {
+		img, err := decodeImage(p.Image)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+		data.Image = img
+	}
+	data.RawData = bytes.NewReader(b)
+
+	return &data, nil
+}
+
+// decodeImage decodes a base64 image, with or without a data URL prefix.
+func decodeImage(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "data:") {
+		if i := strings.IndexByte(s, ','); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+
+	s = strings.TrimRight(s, "=")
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,141 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"giautm.dev/hanetai/webhook"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+type message struct {
+	paho.Message
+	topic   string
+	payload []byte
+}
+
+func (m *message) Topic() string   { return m.topic }
+func (m *message) Payload() []byte { return m.payload }
+
+func TestDecode(t *testing.T) {
+	img := []byte("\xff\xd8\xff\xe0 face")
+	b64 := base64.StdEncoding.EncodeToString(img)
+	tests := []struct {
+		name      string
+		payload   string
+		wantImage []byte
+		wantErr   bool
+	}{
+		{
+			name:    "without image",
+			payload: `{"data_type":"log","id":"1","personID":"2","placeID":1542}`,
+		},
+		{
+			name:      "base64 image",
+			payload:   fmt.Sprintf(`{"data_type":"log","id":"1","image":%q}`, b64),
+			wantImage: img,
+		},
+		{
+			name:      "data URL image",
+			payload:   fmt.Sprintf(`{"data_type":"log","id":"1","image":"data:image/jpeg;base64,%s"}`, b64),
+			wantImage: img,
+		},
+		{
+			name:    "invalid image",
+			payload: `{"data_type":"log","id":"1","image":"not base64!"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			payload: `{`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.DataType != webhook.DataLog || got.ID != "1" {
+				t.Errorf("Decode() = %+v, want a log event 1", got)
+			}
+			if !bytes.Equal(got.Image, tt.wantImage) {
+				t.Errorf("Decode() Image = %q, want %q", got.Image, tt.wantImage)
+			}
+		})
+	}
+}
+
+func TestNewMessageHandler(t *testing.T) {
+	var (
+		got  []string
+		errs []error
+	)
+	h := NewMessageHandler(webhook.HandlerFunc(func(ctx context.Context, data *webhook.Data) error {
+		got = append(got, data.ID)
+		if data.ID == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}), WithOnError(func(ctx context.Context, err error) {
+		errs = append(errs, err)
+	}))
+
+	for _, p := range []string{`{"id":"1"}`, `{`, `{"id":"fail"}`} {
+		h(nil, &message{topic: "hanet/C21024B155", payload: []byte(p)})
+	}
+
+	if fmt.Sprint(got) != "[1 fail]" {
+		t.Errorf("handled %v, want [1 fail]", got)
+	}
+	if len(errs) != 2 {
+		t.Errorf("OnError = %v, want 2 errors", errs)
+	}
+}
+
+// TestSubscribe runs against the broker at $HANET_MQTT_BROKER, e.g.
+// tcp://127.0.0.1:1883.
+func TestSubscribe(t *testing.T) {
+	broker := os.Getenv("HANET_MQTT_BROKER")
+	if broker == "" {
+		t.Skip("HANET_MQTT_BROKER is not set")
+	}
+
+	c := paho.NewClient(paho.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(fmt.Sprintf("hanetai-test-%d", time.Now().UnixNano())))
+	if tok := c.Connect(); tok.Wait() && tok.Error() != nil {
+		t.Fatalf("Connect() error = %v", tok.Error())
+	}
+	defer c.Disconnect(250)
+
+	topic := fmt.Sprintf("hanetai-test/%d", time.Now().UnixNano())
+	got := make(chan *webhook.Data, 1)
+	err := Subscribe(c, webhook.HandlerFunc(func(ctx context.Context, data *webhook.Data) error {
+		got <- data
+		return nil
+	}), WithTopic(topic))
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	c.Publish(topic, 1, false, `{"data_type":"log","id":"1"}`).Wait()
+	select {
+	case data := <-got:
+		if data.ID != "1" {
+			t.Errorf("Subscribe() handled %+v, want event 1", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Subscribe() handled no event")
+	}
+}
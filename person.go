@@ -72,7 +72,7 @@ var DefaultAvatarSize = AvatarSize{
 
 func (s *PersonService) Register(ctx context.Context, pu PersonRegisterRequest) (*PersonRegisterResponse, error) {
 	req, err := s.client.NewRequest("person/register",
-		multipartBody(pu.File, func(w *multipart.Writer) error {
+		faceBody(pu.File, s.client.FacePreprocessor, func(w *multipart.Writer) error {
 			w.WriteField("name", pu.Name)
 			w.WriteField("aliasID", pu.AliasID)
 			w.WriteField("placeID", fmt.Sprintf("%d", pu.PlaceID))
@@ -130,7 +130,7 @@ func (s *PersonService) RegisterByURL(ctx context.Context, pu PersonRegisterURLR
 
 func (s *PersonService) UpdateByFaceImage(ctx context.Context, pu PersonFaceUpdateRequest) error {
 	req, err := s.client.NewRequest("person/updateByFaceImage",
-		multipartBody(pu.File, func(w *multipart.Writer) error {
+		faceBody(pu.File, s.client.FacePreprocessor, func(w *multipart.Writer) error {
 			w.WriteField("aliasID", pu.AliasID)
 			w.WriteField("placeID", fmt.Sprintf("%d", pu.PlaceID))
 
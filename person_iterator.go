@@ -0,0 +1,95 @@
+package hanetai
+
+import (
+	"context"
+)
+
+// DefaultPageSize is the page size used by PersonIterator when the request
+// doesn't set one.
+const DefaultPageSize = 100
+
+// PersonIterator iterates over every person of a place, fetching the pages
+// on demand.
+//
+//	it := c.Persons.ListByPlaceAll(ctx, hanetai.PersonListByPlaceRequest{PlaceID: 1542})
+//	for it.Next() {
+//		p := it.Person()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PersonIterator struct {
+	s    *PersonService
+	ctx  context.Context
+	data PersonListByPlaceRequest
+
+	items []PersonListItem
+	cur   PersonListItem
+	last  bool
+	err   error
+}
+
+// ListByPlaceAll returns an iterator over the persons of the place, starting
+// at data.Page (or the first page) and reading data.Size persons per request.
+// The iteration stops after a page shorter than the page size.
+func (s *PersonService) ListByPlaceAll(ctx context.Context, data PersonListByPlaceRequest) *PersonIterator {
+	if data.Page < 1 {
+		data.Page = 1
+	}
+	if data.Size < 1 {
+		data.Size = DefaultPageSize
+	}
+
+	return &PersonIterator{
+		s:    s,
+		ctx:  ctx,
+		data: data,
+	}
+}
+
+// Next advances to the next person, it returns false when the iteration is
+// finished or failed, see Err.
+func (it *PersonIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	for len(it.items) == 0 {
+		if it.last {
+			return false
+		}
+
+		items, err := it.s.ListByPlace(it.ctx, it.data)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.items = items
+		it.last = len(items) < it.data.Size
+		it.data.Page++
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Person returns the current person.
+func (it *PersonIterator) Person() PersonListItem {
+	return it.cur
+}
+
+// Page returns the page that will be fetched next.
+func (it *PersonIterator) Page() int {
+	return it.data.Page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PersonIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,102 @@
+package hanetai
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"giautm.dev/hanetai/hanettest"
+)
+
+func TestPersonService_ListByPlaceAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		persons   int
+		size      int
+		wantCount int
+		wantCalls int
+	}{
+		{
+			name:      "short last page",
+			persons:   5,
+			size:      2,
+			wantCount: 5,
+			wantCalls: 3,
+		},
+		{
+			name:      "full last page",
+			persons:   4,
+			size:      2,
+			wantCount: 4,
+			wantCalls: 3,
+		},
+		{
+			name:      "empty place",
+			persons:   0,
+			size:      2,
+			wantCount: 0,
+			wantCalls: 1,
+		},
+		{
+			name:      "default page size",
+			persons:   3,
+			wantCount: 3,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := newTestClient(t)
+			place := srv.AddPlace(hanettest.Place{Name: "Branch"})
+			for i := 0; i < tt.persons; i++ {
+				srv.AddPerson(hanettest.Person{
+					AliasID: fmt.Sprint(i),
+					PlaceID: place.ID,
+					Name:    fmt.Sprint("Person ", i),
+				})
+			}
+
+			it := c.Persons.ListByPlaceAll(context.Background(), PersonListByPlaceRequest{
+				PlaceID: place.ID,
+				Size:    tt.size,
+			})
+			count := 0
+			for it.Next() {
+				if got, want := it.Person().AliasID, fmt.Sprint(count); got != want {
+					t.Errorf("PersonIterator.Person() = %v, want %v", got, want)
+				}
+				count++
+			}
+			if err := it.Err(); err != nil {
+				t.Errorf("PersonIterator.Err() = %v", err)
+			}
+			if count != tt.wantCount {
+				t.Errorf("PersonIterator count = %v, want %v", count, tt.wantCount)
+			}
+			if got := srv.Calls("person/getListByPlace"); got != tt.wantCalls {
+				t.Errorf("person/getListByPlace calls = %v, want %v", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPersonIterator_Canceled(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := c.Persons.ListByPlaceAll(ctx, PersonListByPlaceRequest{
+		PlaceID: 1542,
+		Size:    1,
+	})
+	if !it.Next() {
+		t.Fatalf("PersonIterator.Next() = false, err %v", it.Err())
+	}
+	cancel()
+
+	if it.Next() {
+		t.Errorf("PersonIterator.Next() = true after cancel")
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("PersonIterator.Err() = %v, want %v", it.Err(), context.Canceled)
+	}
+}
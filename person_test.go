@@ -3,17 +3,15 @@ package hanetai
 import (
 	"context"
 	"net/url"
-	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestPersonService_Register(t *testing.T) {
-	f, err := os.Open("/workspace/avatar-xoay.jpg")
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
+	c, _ := newTestClient(t)
+
+	f := strings.NewReader("avatar-xoay.jpg")
 
 	type fields struct {
 		client *Client
@@ -32,7 +30,7 @@ func TestPersonService_Register(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -63,11 +61,9 @@ func TestPersonService_Register(t *testing.T) {
 }
 
 func TestPersonService_UpdateByFaceImage(t *testing.T) {
-	f, err := os.Open("/workspace/avatar-xoay.jpg")
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
+	c, _ := newTestClient(t)
+
+	f := strings.NewReader("avatar-xoay.jpg")
 
 	type fields struct {
 		client *Client
@@ -85,7 +81,7 @@ func TestPersonService_UpdateByFaceImage(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -111,6 +107,8 @@ func TestPersonService_UpdateByFaceImage(t *testing.T) {
 }
 
 func TestPersonService_Remove(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -127,7 +125,7 @@ func TestPersonService_Remove(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -151,6 +149,8 @@ func TestPersonService_Remove(t *testing.T) {
 }
 
 func TestPersonService_RemoveByPlace(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -167,7 +167,7 @@ func TestPersonService_RemoveByPlace(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -192,6 +192,8 @@ func TestPersonService_RemoveByPlace(t *testing.T) {
 }
 
 func TestPersonService_Update(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -208,7 +210,7 @@ func TestPersonService_Update(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -235,6 +237,8 @@ func TestPersonService_Update(t *testing.T) {
 }
 
 func TestPersonService_UpdateAliasID(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -251,7 +255,7 @@ func TestPersonService_UpdateAliasID(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -278,6 +282,8 @@ func TestPersonService_UpdateAliasID(t *testing.T) {
 }
 
 func TestPersonService_ListByPlace(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -295,7 +301,7 @@ func TestPersonService_ListByPlace(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -304,6 +310,20 @@ func TestPersonService_ListByPlace(t *testing.T) {
 					Type:    "0",
 				},
 			},
+			want: []PersonListItem{
+				{
+					Name:     "Nguyễn Văn A",
+					AliasID:  "VCFL1231231",
+					PersonID: "1858497629510868990",
+					Title:    "Nhân viên",
+				},
+				{
+					Name:     "Trần Thị B",
+					AliasID:  "852576",
+					PersonID: "1858497629510868992",
+					Title:    "Nhân viên",
+				},
+			},
 			wantErr: false,
 		},
 	}
@@ -365,6 +385,8 @@ func TestAvaterSize_SetUrlValues(t *testing.T) {
 }
 
 func TestPersonService_TakeFacePicture(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -381,7 +403,7 @@ func TestPersonService_TakeFacePicture(t *testing.T) {
 		{
 			name: "happy case checkin_picture",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -38,7 +38,7 @@ func (s *PlaceService) UpdatePlace(ctx context.Context, place Place) error {
 }
 
 func (s *PlaceService) Places(ctx context.Context) ([]Place, error) {
-	req, err := s.client.NewRequest("place/getPlaces", nil)
+	req, err := s.client.NewRequest("place/getPlaces", urlencodeBody(nil))
 	if err != nil {
 		return nil, err
 	}
@@ -2,18 +2,13 @@ package hanetai
 
 import (
 	"context"
-	"os"
 	"reflect"
 	"testing"
-
-	"golang.org/x/oauth2"
 )
 
-var ts = oauth2.StaticTokenSource(&oauth2.Token{
-	AccessToken: os.Getenv("HANET_ACCESS_TOKEN"),
-})
-
 func TestPlaceService_AddPlace(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -31,7 +26,7 @@ func TestPlaceService_AddPlace(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -41,6 +36,7 @@ func TestPlaceService_AddPlace(t *testing.T) {
 				},
 			},
 			want: &Place{
+				ID:      1001,
 				Name:    "My Happy Case",
 				Address: "Ù ú u",
 			},
@@ -65,6 +61,8 @@ func TestPlaceService_AddPlace(t *testing.T) {
 }
 
 func TestPlaceService_UpdatePlace(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -81,12 +79,12 @@ func TestPlaceService_UpdatePlace(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
 				place: Place{
-					ID:      1790,
+					ID:      1542,
 					Name:    "My Happy Case",
 					Address: "Ù ú u",
 				},
@@ -107,6 +105,8 @@ func TestPlaceService_UpdatePlace(t *testing.T) {
 }
 
 func TestPlaceService_Places(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -123,12 +123,18 @@ func TestPlaceService_Places(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
 			},
-			want:    []Place{},
+			want: []Place{
+				{
+					ID:      1542,
+					Name:    "Hanet HQ",
+					Address: "Hà Nội",
+				},
+			},
 			wantErr: false,
 		},
 	}
@@ -150,6 +156,8 @@ func TestPlaceService_Places(t *testing.T) {
 }
 
 func TestPlaceService_Remove(t *testing.T) {
+	c, _ := newTestClient(t)
+
 	type fields struct {
 		client *Client
 	}
@@ -166,7 +174,7 @@ func TestPlaceService_Remove(t *testing.T) {
 		{
 			name: "happy case",
 			fields: fields{
-				client: NewClient(nil, ts),
+				client: c,
 			},
 			args: args{
 				ctx: context.Background(),
@@ -0,0 +1,128 @@
+package hanetai
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how Client.Do retries a failed API request.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value less than or equal to 1 disables retrying.
+	MaxAttempts int
+
+	// MinBackoff is the delay before the second attempt. Each following
+	// attempt doubles the delay, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction (0..1) of each delay that is randomized, so
+	// concurrent callers don't retry in lockstep.
+	Jitter float64
+
+	// RetriableCodes are the API return codes retried in addition to the
+	// transport errors and the 429 and 5xx responses.
+	RetriableCodes []int
+
+	// Retriable reports whether the error should be retried, e.g.
+	// IsRetriable to also retry the API errors not known to be permanent.
+	// The transport errors, the 429 and 5xx responses and RetriableCodes
+	// are retried when it is nil.
+	Retriable func(error) bool
+}
+
+// DefaultRetryPolicy is a reasonable policy for the Hanet partner API.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+	Jitter:      0.5,
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) retriable(err error) bool {
+	if p.Retriable != nil {
+		return p.Retriable(err)
+	}
+
+	if se, ok := err.(*ServerError); ok {
+		for _, code := range p.RetriableCodes {
+			if se.Code == code {
+				return true
+			}
+		}
+	}
+	return isTransient(err)
+}
+
+// backoff returns the delay before the given attempt, attempt starts at 1
+// for the first retry.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if j := p.Jitter; j > 0 && d > 0 {
+		if j > 1 {
+			j = 1
+		}
+		d -= time.Duration(rand.Float64() * j * float64(d))
+	}
+	return d
+}
+
+// nonIdempotent are the endpoints failing when sent again after the first
+// request was applied, e.g. with -9005 when the person was registered.
+var nonIdempotent = map[string]bool{
+	"department/add":       true,
+	"person/register":      true,
+	"person/registerByUrl": true,
+	"person/updateAliasID": true,
+	"place/addPlace":       true,
+}
+
+// retryPolicy returns the policy that applies to the request, endpoint overrides
+// take precedence over the client-wide policy. The non-idempotent endpoints
+// are only retried with an override.
+func (c *Client) retryPolicy(req *http.Request) *RetryPolicy {
+	endpoint := strings.TrimPrefix(req.URL.Path, c.BaseURL.Path)
+	if p, ok := c.RetryOverrides[endpoint]; ok {
+		return p
+	}
+	if nonIdempotent[endpoint] {
+		return nil
+	}
+
+	return c.RetryPolicy
+}
+
+// sleep waits for d, or returns false if ctx is done first or its deadline
+// would pass before d has elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
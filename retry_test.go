@@ -0,0 +1,261 @@
+package hanetai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newHandlerTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	c := NewClient(nil, ts)
+	c.BaseURL, _ = url.Parse(srv.URL + "/")
+	return c
+}
+
+func TestClient_Do_Retry(t *testing.T) {
+	type args struct {
+		policy    *RetryPolicy
+		overrides map[string]*RetryPolicy
+		failures  int
+		code      int
+		status    int
+	}
+	fastPolicy := &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  2 * time.Millisecond,
+		Jitter:      0.5,
+	}
+	registerPolicy := map[string]*RetryPolicy{
+		"person/register": fastPolicy,
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name: "no policy sends once",
+			args: args{
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name: "unavailable server succeeds after retry",
+			args: args{
+				overrides: registerPolicy,
+				failures:  2,
+				status:    http.StatusServiceUnavailable,
+			},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name: "gives up after max attempts",
+			args: args{
+				overrides: registerPolicy,
+				failures:  5,
+				status:    http.StatusBadGateway,
+			},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name: "API error is not retried",
+			args: args{
+				overrides: registerPolicy,
+				failures:  1,
+				code:      errCodeEmployeeIsExists,
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name: "retriable code is retried",
+			args: args{
+				overrides: map[string]*RetryPolicy{
+					"person/register": {MaxAttempts: 3, RetriableCodes: []int{-1}},
+				},
+				failures: 2,
+				code:     -1,
+			},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name: "client policy doesn't retry register",
+			args: args{
+				policy:   fastPolicy,
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name: "endpoint override disables retry",
+			args: args{
+				policy: fastPolicy,
+				overrides: map[string]*RetryPolicy{
+					"person/register": nil,
+				},
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			c := newHandlerTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Errorf("ParseMultipartForm() error = %v", err)
+				}
+				f, _, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("FormFile() error = %v", err)
+				} else if b, _ := io.ReadAll(f); string(b) != "face" {
+					t.Errorf("file = %q, want %q", b, "face")
+				}
+
+				if n := atomic.AddInt32(&attempts, 1); int(n) <= tt.args.failures {
+					if tt.args.status != 0 {
+						w.WriteHeader(tt.args.status)
+						return
+					}
+					fmt.Fprintf(w, `{"returnCode":%d,"returnMessage":"failed"}`, tt.args.code)
+					return
+				}
+				fmt.Fprint(w, `{"returnCode":1,"data":{"personID":"1"}}`)
+			})
+			c.RetryPolicy = tt.args.policy
+			c.RetryOverrides = tt.args.overrides
+
+			_, err := c.Persons.Register(context.Background(), PersonRegisterRequest{
+				PersonFaceUpdateRequest: &PersonFaceUpdateRequest{
+					AliasID: "1",
+					PlaceID: 1,
+					File:    strings.NewReader("face"),
+				},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Client.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("Client.Do() attempts = %v, want %v", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func Test_isTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "transport", err: &url.Error{Op: "Post", URL: "https://partner.hanet.ai/", Err: io.EOF}, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "canceled", err: &url.Error{Op: "Post", Err: context.Canceled}, want: false},
+		{name: "503", err: &StatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "429", err: &StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "404", err: &StatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "API error", err: &ServerError{Code: -1}, want: false},
+		{name: "employee exists", err: &ServerError{Code: errCodeEmployeeIsExists}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetriable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "transport", err: &url.Error{Op: "Post", Err: io.EOF}, want: true},
+		{name: "API error", err: &ServerError{Code: -1}, want: true},
+		{name: "employee exists", err: &ServerError{Code: errCodeEmployeeIsExists}, want: false},
+		{name: "invalid image", err: &ServerError{Code: errCodeInvalidImage}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetriable(tt.err); got != tt.want {
+				t.Errorf("IsRetriable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Do_RetryHonorsDeadline(t *testing.T) {
+	var attempts int32
+	c := newHandlerTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 5,
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Second,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.Profile.Me(ctx); err == nil {
+		t.Errorf("Client.Do() error = nil, want error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Client.Do() took %v, want it to give up before the deadline", elapsed)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("Client.Do() attempts = %v, want %v", got, 1)
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 300 * time.Millisecond,
+	}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 300 * time.Millisecond},
+		{attempt: 10, want: 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			if got := p.backoff(tt.attempt); got != tt.want {
+				t.Errorf("RetryPolicy.backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
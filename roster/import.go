@@ -0,0 +1,146 @@
+package roster
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"giautm.dev/hanetai"
+)
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+
+	// StatusExists is a row whose aliasID is already registered at the
+	// place, e.g. registered by a run that crashed before writing its
+	// result. It counts as done.
+	StatusExists = "exists"
+)
+
+// codeEmployeeIsExists is returned when registering an aliasID twice.
+const codeEmployeeIsExists = -9005
+
+// ImportResult is the outcome of the registration of a row.
+type ImportResult struct {
+	AliasID  string `json:"aliasID"`
+	Status   string `json:"status"`
+	PersonID string `json:"personID,omitempty"`
+
+	// Code is the return code of the ServerError, if the row failed because
+	// of one.
+	Code  int    `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Importer registers many persons at a place.
+type Importer struct {
+	Persons *hanetai.PersonService
+
+	// Concurrency is the maximum number of registrations in flight, defaults
+	// to 4.
+	Concurrency int
+}
+
+// Import registers the persons at the place, skipping the aliasIDs in done.
+// fn is called once per registered row, never concurrently, so it can append
+// the result to a file; an error returned by fn stops the import.
+func (i *Importer) Import(ctx context.Context, placeID int, persons []Person, done map[string]bool, fn func(ImportResult) error) error {
+	concurrency := i.Concurrency
+	if concurrency < 1 {
+		concurrency = 4
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		fnErr error
+		wg    sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, p := range persons {
+		if done[p.AliasID] {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(p Person) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			personID, err := Register(ctx, i.Persons, placeID, p)
+			res := importResult(p.AliasID, personID, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if fnErr != nil {
+				return
+			}
+			if fnErr = fn(res); fnErr != nil {
+				cancel()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	if fnErr != nil {
+		return fnErr
+	}
+	return ctx.Err()
+}
+
+func importResult(aliasID, personID string, err error) ImportResult {
+	if err == nil {
+		return ImportResult{
+			AliasID:  aliasID,
+			Status:   StatusOK,
+			PersonID: personID,
+		}
+	}
+
+	res := ImportResult{
+		AliasID: aliasID,
+		Status:  StatusFailed,
+		Error:   err.Error(),
+	}
+	var serr *hanetai.ServerError
+	if errors.As(err, &serr) {
+		res.Code = serr.Code
+		if serr.Code == codeEmployeeIsExists {
+			res.Status = StatusExists
+		}
+	}
+	return res
+}
+
+// ReadImportResults reads a results file written as JSON lines, and returns
+// the aliasIDs that were imported successfully or already existed. A later
+// line of the same aliasID overrides the earlier ones.
+func ReadImportResults(r io.Reader) (map[string]bool, error) {
+	done := map[string]bool{}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var res ImportResult
+		if err := json.Unmarshal(sc.Bytes(), &res); err != nil {
+			// A partial line is left behind if the import was killed
+			// while writing it, the row is retried.
+			continue
+		}
+		done[res.AliasID] = res.Status == StatusOK || res.Status == StatusExists
+	}
+
+	return done, sc.Err()
+}
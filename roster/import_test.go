@@ -0,0 +1,115 @@
+package roster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"giautm.dev/hanetai/hanettest"
+)
+
+func TestImporter_Import(t *testing.T) {
+	r, srv := newTestReconciler(t)
+	imp := &Importer{Persons: r.Persons, Concurrency: 2}
+
+	persons := []Person{
+		{AliasID: "a", Name: "A", Photo: "https://example.com/a.jpg"},
+		{AliasID: "b", Name: "B", Photo: "https://example.com/b.jpg"},
+		{AliasID: "keep", Name: "Keep", Photo: "https://example.com/keep.jpg"},
+		{AliasID: "c", Name: "C", Photo: "https://example.com/c.jpg"},
+	}
+
+	var results bytes.Buffer
+	write := func(res ImportResult) error {
+		return json.NewEncoder(&results).Encode(res)
+	}
+
+	srv.Fail("person/registerByUrl", hanettest.Failure{
+		Code:    hanettest.CodeInvalidImage,
+		Message: "Invalid image",
+		Times:   1,
+	})
+	if err := imp.Import(context.Background(), 1, persons, nil, write); err != nil {
+		t.Fatalf("Importer.Import() error = %v", err)
+	}
+
+	done, err := ReadImportResults(bytes.NewReader(results.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadImportResults() error = %v", err)
+	}
+	if got := countDone(done); got != 3 {
+		t.Errorf("first run imported %v rows, want %v: %s", got, 3, results.String())
+	}
+
+	var (
+		codes  []int
+		exists []string
+	)
+	dec := json.NewDecoder(bytes.NewReader(results.Bytes()))
+	for dec.More() {
+		var res ImportResult
+		if err := dec.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		switch res.Status {
+		case StatusFailed:
+			codes = append(codes, res.Code)
+		case StatusExists:
+			exists = append(exists, res.AliasID)
+		}
+	}
+	sort.Ints(codes)
+	if want := []int{hanettest.CodeInvalidImage}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("failed codes = %v, want %v", codes, want)
+	}
+	if want := []string{"keep"}; !reflect.DeepEqual(exists, want) {
+		t.Errorf("existing rows = %v, want %v", exists, want)
+	}
+
+	// Resume, only the failed row is sent again.
+	before := srv.Calls("person/registerByUrl")
+	if err := imp.Import(context.Background(), 1, persons, done, write); err != nil {
+		t.Fatalf("Importer.Import() error = %v", err)
+	}
+	if got := srv.Calls("person/registerByUrl") - before; got != 1 {
+		t.Errorf("second run sent %v requests, want %v", got, 1)
+	}
+
+	done, err = ReadImportResults(bytes.NewReader(results.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadImportResults() error = %v", err)
+	}
+	if got := countDone(done); got != 4 {
+		t.Errorf("after resume imported %v rows, want %v", got, 4)
+	}
+}
+
+func TestReadImportResults(t *testing.T) {
+	data := `{"aliasID":"a","status":"failed","code":-9006}` + "\n" +
+		`{"aliasID":"a","status":"ok","personID":"1"}` + "\n" +
+		`{"aliasID":"b","status":"ok","personID":"2"}` + "\n" +
+		`{"aliasID":"d","status":"exists","code":-9005}` + "\n" +
+		`{"aliasID":"c","sta`
+
+	got, err := ReadImportResults(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatalf("ReadImportResults() error = %v", err)
+	}
+	want := map[string]bool{"a": true, "b": true, "d": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadImportResults() = %v, want %v", got, want)
+	}
+}
+
+func countDone(done map[string]bool) int {
+	n := 0
+	for _, ok := range done {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,351 @@
+package roster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"giautm.dev/hanetai"
+)
+
+type ActionType string
+
+const (
+	ActionCreate     = ActionType("create")
+	ActionUpdate     = ActionType("update")
+	ActionUpdateFace = ActionType("update-face")
+	ActionDelete     = ActionType("delete")
+)
+
+// Action is a single change needed to bring a place to the desired state.
+type Action struct {
+	Type    ActionType
+	PlaceID int
+
+	// Person is the desired person, it only has AliasID for deletions.
+	Person Person
+
+	// Current is the person registered at the place, nil for creations.
+	Current *hanetai.PersonListItem
+}
+
+// Plan is the list of actions for a place, ordered deletions, creations,
+// then updates. Apply runs each group once the previous one is done.
+type Plan struct {
+	PlaceID int
+	Actions []Action
+}
+
+// Count returns the number of actions of the given type.
+func (p *Plan) Count(t ActionType) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+// Result is the outcome of an applied action.
+type Result struct {
+	Action Action
+
+	// PersonID is set for successful creations.
+	PersonID string
+	Err      error
+}
+
+// Reconciler computes and applies the changes between the persons of a
+// place and a desired roster.
+type Reconciler struct {
+	Persons *hanetai.PersonService
+
+	// PersonType limits the persons deleted from the place, and is used for
+	// desired persons without a type. It defaults to employees ("0"), so
+	// the customers are never deleted by an employee roster. The persons of
+	// the other types in the roster are matched but never deleted.
+	PersonType string
+
+	// Concurrency is the maximum number of requests in flight while applying
+	// a plan, defaults to 4.
+	Concurrency int
+
+	// DryRun makes Sync return the plan without applying it.
+	DryRun bool
+
+	// NoDelete keeps the persons that are not in the roster.
+	NoDelete bool
+
+	// FaceChanged reports whether the face of an existing person must be
+	// replaced. Faces of existing persons are never updated when it is nil.
+	FaceChanged func(current hanetai.PersonListItem, desired Person) bool
+}
+
+func (r *Reconciler) personType() string {
+	if r.PersonType == "" {
+		return "0"
+	}
+	return r.PersonType
+}
+
+// Plan compares the persons of the place with the desired roster. An empty
+// name or title of the roster is not managed, the current one is kept.
+func (r *Reconciler) Plan(ctx context.Context, placeID int, desired []Person) (*Plan, error) {
+	want := make(map[string]Person, len(desired))
+	types := map[string]bool{r.personType(): true}
+	for _, p := range desired {
+		if _, ok := want[p.AliasID]; ok {
+			return nil, fmt.Errorf("roster: duplicated aliasID %q", p.AliasID)
+		}
+		if p.Type == "" {
+			p.Type = r.personType()
+		}
+		want[p.AliasID] = p
+		types[p.Type] = true
+	}
+
+	// The place is listed once per type, as the persons are listed by type.
+	current := map[string]hanetai.PersonListItem{}
+	managed := map[string]bool{}
+	for _, typ := range sortedKeys(types) {
+		it := r.Persons.ListByPlaceAll(ctx, hanetai.PersonListByPlaceRequest{
+			PlaceID: placeID,
+			Type:    typ,
+		})
+		for it.Next() {
+			p := it.Person()
+			current[p.AliasID] = p
+			if typ == r.personType() {
+				managed[p.AliasID] = true
+			}
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &Plan{PlaceID: placeID}
+	if !r.NoDelete {
+		for _, aliasID := range sortedKeys(managed) {
+			if _, ok := want[aliasID]; !ok {
+				c := current[aliasID]
+				plan.Actions = append(plan.Actions, Action{
+					Type:    ActionDelete,
+					PlaceID: placeID,
+					Person:  Person{AliasID: aliasID},
+					Current: &c,
+				})
+			}
+		}
+	}
+
+	var updates []Action
+	for _, p := range desired {
+		p = want[p.AliasID]
+		c, ok := current[p.AliasID]
+		if !ok {
+			plan.Actions = append(plan.Actions, Action{
+				Type:    ActionCreate,
+				PlaceID: placeID,
+				Person:  p,
+			})
+			continue
+		}
+
+		if p.Name == "" {
+			p.Name = c.Name
+		}
+		if p.Title == "" {
+			p.Title = c.Title
+		}
+		if c.Name != p.Name || c.Title != p.Title {
+			updates = append(updates, Action{
+				Type:    ActionUpdate,
+				PlaceID: placeID,
+				Person:  p,
+				Current: &c,
+			})
+		}
+		if p.Photo != "" && r.FaceChanged != nil && r.FaceChanged(c, p) {
+			updates = append(updates, Action{
+				Type:    ActionUpdateFace,
+				PlaceID: placeID,
+				Person:  p,
+				Current: &c,
+			})
+		}
+	}
+	plan.Actions = append(plan.Actions, updates...)
+
+	return plan, nil
+}
+
+// Apply runs the actions of the plan, at most Concurrency at a time, the
+// deletions before the creations and the creations before the updates. The
+// results are in the order of the plan's actions.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) []Result {
+	concurrency := r.Concurrency
+	if concurrency < 1 {
+		concurrency = 4
+	}
+
+	results := make([]Result, len(plan.Actions))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, a := range plan.Actions {
+		results[i].Action = a
+		if i > 0 && phase(a.Type) != phase(plan.Actions[i-1].Type) {
+			wg.Wait()
+		}
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(res *Result) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res.PersonID, res.Err = r.apply(ctx, res.Action)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Sync plans the changes of the place and applies them, unless DryRun is
+// set. The results are nil in dry-run mode.
+func (r *Reconciler) Sync(ctx context.Context, placeID int, desired []Person) (*Plan, []Result, error) {
+	plan, err := r.Plan(ctx, placeID, desired)
+	if err != nil || r.DryRun {
+		return plan, nil, err
+	}
+
+	return plan, r.Apply(ctx, plan), nil
+}
+
+// phase orders the groups of actions applied one after the other.
+func phase(t ActionType) int {
+	switch t {
+	case ActionDelete:
+		return 0
+	case ActionCreate:
+		return 1
+	}
+	return 2
+}
+
+func (r *Reconciler) apply(ctx context.Context, a Action) (string, error) {
+	p := a.Person
+	switch a.Type {
+	case ActionCreate:
+		return Register(ctx, r.Persons, a.PlaceID, p)
+	case ActionUpdate:
+		return "", r.Persons.Update(ctx, hanetai.PersonUpdateRequest{
+			AliasID: p.AliasID,
+			PlaceID: a.PlaceID,
+			Name:    p.Name,
+			Title:   p.Title,
+		})
+	case ActionUpdateFace:
+		return "", updateFace(ctx, r.Persons, a.PlaceID, p)
+	case ActionDelete:
+		return "", r.Persons.RemoveByPlace(ctx, hanetai.PersonRemoveByPlaceRequest{
+			AliasID: p.AliasID,
+			PlaceID: a.PlaceID,
+		})
+	}
+
+	return "", fmt.Errorf("roster: unknown action %q", a.Type)
+}
+
+// Register registers the person at the place with its photo, using
+// RegisterByURL for photo URLs. Persons without a type are registered as
+// employees. It returns the ID of the new person.
+func Register(ctx context.Context, s *hanetai.PersonService, placeID int, p Person) (string, error) {
+	if p.Photo == "" {
+		return "", fmt.Errorf("roster: %q has no photo", p.AliasID)
+	}
+	if p.Type == "" {
+		p.Type = "0"
+	}
+
+	var (
+		resp *hanetai.PersonRegisterResponse
+		err  error
+	)
+	if p.PhotoIsURL() {
+		resp, err = s.RegisterByURL(ctx, hanetai.PersonRegisterURLRequest{
+			PersonFaceURLUpdateRequest: &hanetai.PersonFaceURLUpdateRequest{
+				AliasID: p.AliasID,
+				PlaceID: placeID,
+				FileURL: p.Photo,
+			},
+			Name:  p.Name,
+			Title: p.Title,
+			Type:  p.Type,
+		})
+	} else {
+		var f *os.File
+		f, err = os.Open(p.Photo)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		resp, err = s.Register(ctx, hanetai.PersonRegisterRequest{
+			PersonFaceUpdateRequest: &hanetai.PersonFaceUpdateRequest{
+				AliasID: p.AliasID,
+				PlaceID: placeID,
+				File:    f,
+			},
+			Name:  p.Name,
+			Title: p.Title,
+			Type:  p.Type,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+func updateFace(ctx context.Context, s *hanetai.PersonService, placeID int, p Person) error {
+	if p.PhotoIsURL() {
+		return s.UpdateByFaceURL(ctx, hanetai.PersonFaceURLUpdateRequest{
+			AliasID: p.AliasID,
+			PlaceID: placeID,
+			FileURL: p.Photo,
+		})
+	}
+
+	f, err := os.Open(p.Photo)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.UpdateByFaceImage(ctx, hanetai.PersonFaceUpdateRequest{
+		AliasID: p.AliasID,
+		PlaceID: placeID,
+		File:    f,
+	})
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,215 @@
+package roster
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"giautm.dev/hanetai"
+	"giautm.dev/hanetai/hanettest"
+	"golang.org/x/oauth2"
+)
+
+func newTestReconciler(t *testing.T) (*Reconciler, *hanettest.Server) {
+	srv := hanettest.NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.AddPlace(hanettest.Place{ID: 1, Name: "HQ"})
+	srv.AddPerson(hanettest.Person{AliasID: "keep", PlaceID: 1, Name: "Keep", Title: "Dev", Face: "keep"})
+	srv.AddPerson(hanettest.Person{AliasID: "rename", PlaceID: 1, Name: "Old", Title: "Dev", Face: "rename"})
+	srv.AddPerson(hanettest.Person{AliasID: "leave", PlaceID: 1, Name: "Leave", Face: "leave"})
+
+	c := hanetai.NewClient(srv.Client(), oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: "token",
+	}))
+	c.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	return &Reconciler{Persons: c.Persons}, srv
+}
+
+func actionsOf(plan *Plan) map[string]ActionType {
+	m := map[string]ActionType{}
+	for _, a := range plan.Actions {
+		m[string(a.Type)+":"+a.Person.AliasID] = a.Type
+	}
+	return m
+}
+
+func TestReconciler_Plan(t *testing.T) {
+	photo := filepath.Join(t.TempDir(), "new.jpg")
+	if err := os.WriteFile(photo, []byte("new-face"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	desired := []Person{
+		{AliasID: "keep", Name: "Keep", Title: "Dev", Photo: "https://example.com/keep.jpg"},
+		{AliasID: "rename", Name: "New", Title: "Dev"},
+		{AliasID: "new", Name: "New", Photo: photo},
+		{AliasID: "new-url", Name: "New URL", Photo: "https://example.com/new.jpg"},
+	}
+
+	tests := []struct {
+		name        string
+		noDelete    bool
+		faceChanged func(hanetai.PersonListItem, Person) bool
+		want        map[string]ActionType
+	}{
+		{
+			name: "happy case",
+			want: map[string]ActionType{
+				"delete:leave":   ActionDelete,
+				"create:new":     ActionCreate,
+				"create:new-url": ActionCreate,
+				"update:rename":  ActionUpdate,
+			},
+		},
+		{
+			name:     "no delete and face changed",
+			noDelete: true,
+			faceChanged: func(hanetai.PersonListItem, Person) bool {
+				return true
+			},
+			want: map[string]ActionType{
+				"create:new":       ActionCreate,
+				"create:new-url":   ActionCreate,
+				"update:rename":    ActionUpdate,
+				"update-face:keep": ActionUpdateFace,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, srv := newTestReconciler(t)
+			r.NoDelete = tt.noDelete
+			r.FaceChanged = tt.faceChanged
+
+			plan, err := r.Plan(context.Background(), 1, desired)
+			if err != nil {
+				t.Fatalf("Reconciler.Plan() error = %v", err)
+			}
+			if got := actionsOf(plan); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reconciler.Plan() = %v, want %v", got, tt.want)
+			}
+
+			for _, res := range r.Apply(context.Background(), plan) {
+				if res.Err != nil {
+					t.Errorf("Reconciler.Apply() %s %s error = %v", res.Action.Type, res.Action.Person.AliasID, res.Err)
+				}
+				if res.Action.Type == ActionCreate && res.PersonID == "" {
+					t.Errorf("Reconciler.Apply() create %s has no personID", res.Action.Person.AliasID)
+				}
+			}
+
+			again, err := r.Plan(context.Background(), 1, desired)
+			if err != nil {
+				t.Fatalf("Reconciler.Plan() error = %v", err)
+			}
+			if n := len(again.Actions) - again.Count(ActionUpdateFace); n != 0 {
+				t.Errorf("Reconciler.Plan() after Apply = %v, want no changes", actionsOf(again))
+			}
+
+			for _, p := range srv.Persons(1) {
+				if p.AliasID == "rename" && p.Name != "New" {
+					t.Errorf("person %q name = %q, want %q", p.AliasID, p.Name, "New")
+				}
+			}
+		})
+	}
+}
+
+func TestReconciler_SyncDryRun(t *testing.T) {
+	r, srv := newTestReconciler(t)
+	r.DryRun = true
+
+	plan, results, err := r.Sync(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("Reconciler.Sync() error = %v", err)
+	}
+	if results != nil {
+		t.Errorf("Reconciler.Sync() results = %v, want nil", results)
+	}
+	if got := plan.Count(ActionDelete); got != 3 {
+		t.Errorf("Plan.Count(delete) = %v, want %v", got, 3)
+	}
+	if got := len(srv.Persons(1)); got != 3 {
+		t.Errorf("Persons() = %v, want untouched", got)
+	}
+}
+
+func TestReconciler_PlanDuplicatedAlias(t *testing.T) {
+	r, _ := newTestReconciler(t)
+
+	_, err := r.Plan(context.Background(), 1, []Person{{AliasID: "a"}, {AliasID: "a"}})
+	if err == nil {
+		t.Errorf("Reconciler.Plan() error = nil, want duplicated aliasID")
+	}
+}
+
+func TestReconciler_PlanKeepsOtherTypes(t *testing.T) {
+	r, srv := newTestReconciler(t)
+	srv.AddPerson(hanettest.Person{AliasID: "customer", PlaceID: 1, Name: "Customer", Type: 1, Face: "customer"})
+
+	plan, err := r.Plan(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("Reconciler.Plan() error = %v", err)
+	}
+	if _, ok := actionsOf(plan)["delete:customer"]; ok {
+		t.Errorf("Reconciler.Plan() = %v, want the customer kept", actionsOf(plan))
+	}
+	if got := plan.Count(ActionDelete); got != 3 {
+		t.Errorf("Plan.Count(delete) = %v, want %v", got, 3)
+	}
+}
+
+func TestReconciler_SyncOtherType(t *testing.T) {
+	r, srv := newTestReconciler(t)
+	srv.AddPerson(hanettest.Person{AliasID: "c1", PlaceID: 1, Name: "Customer", Type: 1, Face: "c1"})
+	srv.AddPerson(hanettest.Person{AliasID: "c2", PlaceID: 1, Name: "Other", Type: 1, Face: "c2"})
+
+	desired := []Person{{AliasID: "c1", Name: "Customer", Type: "1"}}
+	plan, err := r.Plan(context.Background(), 1, desired)
+	if err != nil {
+		t.Fatalf("Reconciler.Plan() error = %v", err)
+	}
+	actions := actionsOf(plan)
+	if _, ok := actions["create:c1"]; ok {
+		t.Errorf("Reconciler.Plan() = %v, want c1 found at the place", actions)
+	}
+	if _, ok := actions["delete:c2"]; ok {
+		t.Errorf("Reconciler.Plan() = %v, want the other customers kept", actions)
+	}
+
+	for _, res := range r.Apply(context.Background(), plan) {
+		if res.Err != nil {
+			t.Errorf("Reconciler.Apply() %v error = %v", res.Action.Type, res.Err)
+		}
+	}
+}
+
+func TestReconciler_PlanUnmanagedFields(t *testing.T) {
+	r, _ := newTestReconciler(t)
+
+	plan, err := r.Plan(context.Background(), 1, []Person{
+		{AliasID: "keep"},
+		{AliasID: "rename", Name: "New"},
+		{AliasID: "leave", Title: "Manager"},
+	})
+	if err != nil {
+		t.Fatalf("Reconciler.Plan() error = %v", err)
+	}
+
+	want := map[string]Person{
+		"rename": {AliasID: "rename", Name: "New", Title: "Dev", Type: "0"},
+		"leave":  {AliasID: "leave", Name: "Leave", Title: "Manager", Type: "0"},
+	}
+	got := map[string]Person{}
+	for _, a := range plan.Actions {
+		got[a.Person.AliasID] = a.Person
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Reconciler.Plan() = %v, want %v", got, want)
+	}
+}
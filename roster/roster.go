@@ -0,0 +1,135 @@
+// Package roster keeps the persons of Hanet places in line with a roster
+// kept elsewhere, e.g. in an HR system.
+package roster
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Person is the desired state of a person at a place.
+type Person struct {
+	AliasID string `json:"aliasID"`
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Type    string `json:"type"`
+
+	// Photo is the face of the person, either a local file path or an
+	// http(s) URL.
+	Photo string `json:"photo"`
+}
+
+// PhotoIsURL reports whether Photo is a URL rather than a local file.
+func (p Person) PhotoIsURL() bool {
+	return strings.HasPrefix(p.Photo, "http://") || strings.HasPrefix(p.Photo, "https://")
+}
+
+// ReadCSV reads persons from a CSV file with a header row. The columns are
+// matched by name, ignoring case, "_" and "-": aliasID, name, title, type and
+// photo. Only aliasID is required.
+func ReadCSV(r io.Reader) ([]Person, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[normalizeColumn(h)] = i
+	}
+	if _, ok := cols["aliasid"]; !ok {
+		return nil, errors.New("roster: missing aliasID column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var persons []Person
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return persons, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := Person{
+			AliasID: field(record, "aliasid"),
+			Name:    field(record, "name"),
+			Title:   field(record, "title"),
+			Type:    field(record, "type"),
+			Photo:   field(record, "photo"),
+		}
+		if p.AliasID == "" {
+			return nil, fmt.Errorf("roster: row %d: empty aliasID", row)
+		}
+		persons = append(persons, p)
+	}
+}
+
+// ReadJSONL reads persons from a file with a JSON object per line, using the
+// JSON names of Person. Blank lines are skipped.
+func ReadJSONL(r io.Reader) ([]Person, error) {
+	var persons []Person
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		b := sc.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+
+		var p Person
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, fmt.Errorf("roster: line %d: %w", line, err)
+		}
+		if p.AliasID == "" {
+			return nil, fmt.Errorf("roster: line %d: empty aliasID", line)
+		}
+		persons = append(persons, p)
+	}
+
+	return persons, sc.Err()
+}
+
+// ReadFile reads persons from a CSV or JSONL file, the format is picked from
+// the extension of the file: ".jsonl" and ".ndjson" are read as JSONL,
+// anything else as CSV.
+func ReadFile(name string) ([]Person, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		return ReadJSONL(f)
+	}
+	return ReadCSV(f)
+}
+
+func normalizeColumn(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.ReplaceAll(s, "_", "")
+	return strings.ReplaceAll(s, "-", "")
+}
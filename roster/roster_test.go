@@ -0,0 +1,102 @@
+package roster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Person
+		wantErr bool
+	}{
+		{
+			name: "happy case",
+			data: "alias_id,Name,title,type,photo\n" +
+				"1,Nguyễn Văn A,Nhân viên,0,https://example.com/a.jpg\n" +
+				"2, Trần Thị B ,,,b.jpg\n",
+			want: []Person{
+				{AliasID: "1", Name: "Nguyễn Văn A", Title: "Nhân viên", Type: "0", Photo: "https://example.com/a.jpg"},
+				{AliasID: "2", Name: "Trần Thị B", Photo: "b.jpg"},
+			},
+		},
+		{
+			name: "columns in any order",
+			data: "photo,aliasID\n" +
+				"a.jpg,1\n",
+			want: []Person{
+				{AliasID: "1", Photo: "a.jpg"},
+			},
+		},
+		{
+			name:    "missing aliasID column",
+			data:    "name\nA\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty aliasID",
+			data:    "aliasID,name\n,A\n",
+			wantErr: true,
+		},
+		{
+			name: "empty file",
+			data: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Person
+		wantErr bool
+	}{
+		{
+			name: "happy case",
+			data: `{"aliasID":"1","name":"A","photo":"a.jpg"}` + "\n\n" +
+				`{"aliasID":"2","name":"B","type":"1"}` + "\n",
+			want: []Person{
+				{AliasID: "1", Name: "A", Photo: "a.jpg"},
+				{AliasID: "2", Name: "B", Type: "1"},
+			},
+		},
+		{
+			name:    "invalid line",
+			data:    `{"aliasID":"1"}` + "\n" + `{"aliasID":` + "\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty aliasID",
+			data:    `{"name":"A"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJSONL(strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadJSONL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadJSONL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const maxImageSize = 10 << 20
+
+// BlobStore stores the detected images archived by ArchiveImages.
+type BlobStore interface {
+	// Put stores the content of r at key, and returns where it is stored.
+	Put(ctx context.Context, key string, r io.Reader) (string, error)
+}
+
+// DirBlobStore stores the blobs as files in a directory, the key being the
+// path relative to the directory.
+type DirBlobStore string
+
+var _ BlobStore = DirBlobStore("")
+
+func (d DirBlobStore) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("webhook: invalid blob key %q", key)
+	}
+
+	name := filepath.Join(string(d), filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".blob-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+type ArchiveOptions struct {
+	// Backoff is the delay before downloading the image again, doubled
+	// after each failure.
+	Backoff time.Duration
+
+	// Concurrency is the number of images downloaded at the same time.
+	Concurrency int
+
+	HTTPClient *http.Client
+
+	// KeepImage keeps the image in Data.Image for the handler.
+	KeepImage bool
+
+	MaxAttempts int
+
+	// OnError reports the images that can't be archived, the events are
+	// handled without them. When it is nil, the events fail instead and
+	// Hanet delivers them again.
+	OnError func(context.Context, error)
+}
+
+type ArchiveOption = func(*ArchiveOptions)
+
+func WithArchiveBackoff(d time.Duration) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.Backoff = d
+	}
+}
+
+func WithArchiveConcurrency(n int) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.Concurrency = n
+	}
+}
+
+func WithArchiveHTTPClient(c *http.Client) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.HTTPClient = c
+	}
+}
+
+func WithArchiveKeepImage() ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.KeepImage = true
+	}
+}
+
+func WithArchiveMaxAttempts(n int) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.MaxAttempts = n
+	}
+}
+
+func WithArchiveOnError(fn func(context.Context, error)) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.OnError = fn
+	}
+}
+
+// ArchiveImages stores the detected image of the events in store, before
+// the Hanet link to it expires, at <date>/<event ID><ext>. The image is
+// downloaded from DetectedImageURL, unless it is sent along the event (e.g.
+// by MQTT). Data.ImagePath is set to where the image is stored.
+func ArchiveImages(store BlobStore, opts ...ArchiveOption) Middleware {
+	o := &ArchiveOptions{
+		Backoff:     500 * time.Millisecond,
+		Concurrency: 4,
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+		MaxAttempts: 3,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Concurrency < 1 {
+		o.Concurrency = 1
+	}
+	sem := make(chan struct{}, o.Concurrency)
+
+	return func(fn Handler) HandlerFunc {
+		return func(ctx context.Context, data *Data) error {
+			if data.EventData == nil || data.ID == "" {
+				return fn.ServeWebhook(ctx, data)
+			}
+			hasURL := data.PersonData != nil && data.DetectedImageURL != ""
+			if !hasURL && data.Image == nil {
+				return fn.ServeWebhook(ctx, data)
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			err := archiveImage(ctx, store, o, data)
+			<-sem
+
+			if err != nil {
+				err = fmt.Errorf("webhook: archiving the image of event %s: %w", data.ID, err)
+				if o.OnError == nil {
+					return err
+				}
+				o.OnError(ctx, err)
+			}
+			return fn.ServeWebhook(ctx, data)
+		}
+	}
+}
+
+func archiveImage(ctx context.Context, store BlobStore, o *ArchiveOptions, data *Data) error {
+	img := data.Image
+	if img == nil {
+		var err error
+		img, err = downloadImage(ctx, o, data.DetectedImageURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	date := time.Now().In(DateLocation)
+	if t, ok := data.EventTime(); ok {
+		date = t.In(DateLocation)
+	}
+	key := date.Format("2006-01-02") + "/" + data.ID + imageExt(img)
+
+	p, err := store.Put(ctx, key, bytes.NewReader(img))
+	if err != nil {
+		return err
+	}
+
+	data.ImagePath = p
+	if o.KeepImage {
+		data.Image = img
+	}
+	return nil
+}
+
+// errPermanent marks the download errors not worth retrying.
+type errPermanent struct{ error }
+
+func downloadImage(ctx context.Context, o *ArchiveOptions, url string) ([]byte, error) {
+	backoff := o.Backoff
+	for attempt := 1; ; attempt++ {
+		b, err := fetchImage(ctx, o.HTTPClient, url)
+		if err == nil {
+			return b, nil
+		}
+
+		var perm errPermanent
+		if errors.As(err, &perm) {
+			return nil, perm.error
+		}
+		if attempt >= o.MaxAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+func fetchImage(ctx context.Context, c *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errPermanent{err}
+	}
+
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("GET %s: %s", url, resp.Status)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return nil, err
+		}
+		return nil, errPermanent{err}
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxImageSize {
+		return nil, errPermanent{fmt.Errorf("GET %s: image larger than %d bytes", url, maxImageSize)}
+	}
+	return b, nil
+}
+
+func imageExt(b []byte) string {
+	switch t := http.DetectContentType(b); {
+	case t == "image/jpeg":
+		return ".jpg"
+	case strings.HasPrefix(t, "image/"):
+		return "." + strings.TrimPrefix(t, "image/")
+	}
+	return ".bin"
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var jpeg = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00 face")
+
+func TestArchiveImages(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/flaky":
+			if n == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		case "/expired":
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(jpeg)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name         string
+		data         *Data
+		onError      bool
+		wantPath     string
+		wantRequests int32
+		wantErr      bool
+	}{
+		{
+			name: "downloaded after a retry",
+			data: &Data{
+				EventData:  &EventData{ID: "e1", Date: "2021-07-26 10:48:09"},
+				PersonData: &PersonData{DetectedImageURL: srv.URL + "/flaky"},
+			},
+			wantPath:     filepath.Join(dir, "2021-07-26", "e1.jpg"),
+			wantRequests: 2,
+		},
+		{
+			name: "sent along the event",
+			data: &Data{
+				EventData: &EventData{ID: "e2", Date: "2021-07-26 10:48:09"},
+				Image:     jpeg,
+			},
+			wantPath: filepath.Join(dir, "2021-07-26", "e2.jpg"),
+		},
+		{
+			name: "expired fails the event",
+			data: &Data{
+				EventData:  &EventData{ID: "e3"},
+				PersonData: &PersonData{DetectedImageURL: srv.URL + "/expired"},
+			},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name: "expired reported to OnError",
+			data: &Data{
+				EventData:  &EventData{ID: "e4"},
+				PersonData: &PersonData{DetectedImageURL: srv.URL + "/expired"},
+			},
+			onError:      true,
+			wantRequests: 1,
+		},
+		{
+			name: "without image",
+			data: &Data{EventData: &EventData{ID: "e5"}, PersonData: &PersonData{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+			var errs int
+			opts := []ArchiveOption{WithArchiveBackoff(time.Millisecond), WithArchiveKeepImage()}
+			if tt.onError {
+				opts = append(opts, WithArchiveOnError(func(ctx context.Context, err error) {
+					errs++
+				}))
+			}
+
+			var handled *Data
+			h := ArchiveImages(DirBlobStore(dir), opts...)(HandlerFunc(func(ctx context.Context, data *Data) error {
+				handled = data
+				return nil
+			}))
+
+			err := h(context.Background(), tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ArchiveImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("ArchiveImages() requests = %v, want %v", got, tt.wantRequests)
+			}
+			if tt.onError && errs != 1 {
+				t.Errorf("ArchiveImages() OnError calls = %v, want 1", errs)
+			}
+			if err != nil {
+				return
+			}
+			if handled == nil {
+				t.Fatalf("ArchiveImages() didn't call the handler")
+			}
+			if handled.ImagePath != tt.wantPath {
+				t.Errorf("ArchiveImages() ImagePath = %v, want %v", handled.ImagePath, tt.wantPath)
+			}
+			if tt.wantPath != "" {
+				b, err := ioutil.ReadFile(tt.wantPath)
+				if err != nil || string(b) != string(jpeg) {
+					t.Errorf("ArchiveImages() stored %q, %v, want the image", b, err)
+				}
+				if string(handled.Image) != string(jpeg) {
+					t.Errorf("ArchiveImages() Image = %q, want the image", handled.Image)
+				}
+			}
+		})
+	}
+}
+
+func TestDirBlobStore_Put(t *testing.T) {
+	d := DirBlobStore(t.TempDir())
+	for _, key := range []string{"", "../escape.jpg", "a/../../b.jpg", "/abs.jpg"} {
+		if _, err := d.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("DirBlobStore.Put(%q) error = nil, want error", key)
+		}
+	}
+}
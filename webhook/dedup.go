@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEventInFlight is returned by Dedup when the same event is being handled
+// by another delivery, so Hanet retries it later.
+var ErrEventInFlight = errors.New("webhook: event is being handled")
+
+// DedupStore records the IDs of the events handled successfully.
+type DedupStore interface {
+	// Seen reports whether the event was handled.
+	Seen(ctx context.Context, id string) (bool, error)
+
+	// Mark records the event as handled.
+	Mark(ctx context.Context, id string) error
+}
+
+// Dedup skips the events already handled, according to their EventData.ID.
+// An event is marked as handled only after the handler succeeds, so a failed
+// event is handled again when Hanet retries it. Events without an ID are
+// always handled.
+func Dedup(store DedupStore) Middleware {
+	var (
+		mu       sync.Mutex
+		inFlight = map[string]bool{}
+	)
+
+	return func(fn Handler) HandlerFunc {
+		return func(ctx context.Context, data *Data) error {
+			if data.EventData == nil || data.ID == "" {
+				return fn.ServeWebhook(ctx, data)
+			}
+			id := data.ID
+
+			mu.Lock()
+			if inFlight[id] {
+				mu.Unlock()
+				return ErrEventInFlight
+			}
+			inFlight[id] = true
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				delete(inFlight, id)
+				mu.Unlock()
+			}()
+
+			seen, err := store.Seen(ctx, id)
+			if err != nil {
+				return err
+			}
+			if seen {
+				return nil
+			}
+
+			if err := fn.ServeWebhook(ctx, data); err != nil {
+				return err
+			}
+			return store.Mark(ctx, id)
+		}
+	}
+}
+
+// MemoryDedupStore keeps the most recent IDs in memory, each of them for at
+// most a TTL.
+type MemoryDedupStore struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type dedupEntry struct {
+	id     string
+	expiry time.Time
+}
+
+var _ DedupStore = (*MemoryDedupStore)(nil)
+
+// NewMemoryDedupStore returns a store keeping at most size IDs, the least
+// recently marked IDs are evicted first. A zero ttl keeps the IDs until they
+// are evicted.
+func NewMemoryDedupStore(size int, ttl time.Duration) *MemoryDedupStore {
+	return &MemoryDedupStore{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+func (s *MemoryDedupStore) Seen(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[id]
+	if !ok {
+		return false, nil
+	}
+	if s.expired(e.Value.(*dedupEntry)) {
+		s.remove(e)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryDedupStore) Mark(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mark(id, s.now().Add(s.ttl))
+	return nil
+}
+
+// Len returns the number of IDs kept, including the expired ones not yet
+// evicted.
+func (s *MemoryDedupStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ll.Len()
+}
+
+func (s *MemoryDedupStore) mark(id string, expiry time.Time) {
+	if e, ok := s.items[id]; ok {
+		e.Value.(*dedupEntry).expiry = expiry
+		s.ll.MoveToFront(e)
+		return
+	}
+
+	s.items[id] = s.ll.PushFront(&dedupEntry{id: id, expiry: expiry})
+	for s.size > 0 && s.ll.Len() > s.size {
+		s.remove(s.ll.Back())
+	}
+}
+
+func (s *MemoryDedupStore) expired(e *dedupEntry) bool {
+	return s.ttl > 0 && !s.now().Before(e.expiry)
+}
+
+func (s *MemoryDedupStore) remove(e *list.Element) {
+	s.ll.Remove(e)
+	delete(s.items, e.Value.(*dedupEntry).id)
+}
+
+// FileDedupStore keeps the IDs in memory and appends them to a file, so they
+// survive a restart. The file is compacted when it is opened, and when it
+// has twice as many lines as the IDs kept, dropping the evicted and expired
+// IDs.
+type FileDedupStore struct {
+	*MemoryDedupStore
+
+	mu    sync.Mutex
+	path  string
+	f     *os.File
+	lines int
+}
+
+// minCompactLines avoids compacting small files over and over.
+const minCompactLines = 1024
+
+var _ DedupStore = (*FileDedupStore)(nil)
+
+// OpenFileDedupStore opens or creates the store at path, see
+// NewMemoryDedupStore for size and ttl.
+func OpenFileDedupStore(path string, size int, ttl time.Duration) (*FileDedupStore, error) {
+	s := &FileDedupStore{
+		MemoryDedupStore: NewMemoryDedupStore(size, ttl),
+		path:             path,
+	}
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+	if err := s.reopen(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// reopen compacts the file and opens it to append the new IDs.
+func (s *FileDedupStore) reopen() error {
+	s.MemoryDedupStore.mu.Lock()
+	err := s.compact(s.path)
+	s.lines = s.ll.Len()
+	s.MemoryDedupStore.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	s.f = f
+	return nil
+}
+
+func (s *FileDedupStore) Mark(ctx context.Context, id string) error {
+	if strings.ContainsAny(id, "\t\n") {
+		return fmt.Errorf("webhook: invalid event ID %q", id)
+	}
+
+	expiry := s.now().Add(s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return os.ErrClosed
+	}
+	if _, err := fmt.Fprintf(s.f, "%s\t%d\n", id, expiry.UnixNano()); err != nil {
+		return err
+	}
+	s.lines++
+
+	s.MemoryDedupStore.mu.Lock()
+	s.MemoryDedupStore.mark(id, expiry)
+	kept := s.ll.Len()
+	s.MemoryDedupStore.mu.Unlock()
+
+	if s.lines > 2*kept && s.lines > minCompactLines {
+		if err := s.f.Close(); err != nil {
+			return err
+		}
+		s.f = nil
+		return s.reopen()
+	}
+	return nil
+}
+
+func (s *FileDedupStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// load reads the file, later lines of an ID override the earlier ones.
+// A truncated last line is ignored.
+func (s *FileDedupStore) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		parts := strings.SplitN(sc.Text(), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		ns, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		e := &dedupEntry{id: parts[0], expiry: time.Unix(0, ns)}
+		if !s.expired(e) {
+			s.mark(e.id, e.expiry)
+		}
+	}
+	return sc.Err()
+}
+
+// compact rewrites the file with the IDs in memory, oldest first.
+func (s *FileDedupStore) compact(path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for e := s.ll.Back(); e != nil; e = e.Prev() {
+		d := e.Value.(*dedupEntry)
+		fmt.Fprintf(w, "%s\t%d\n", d.id, d.expiry.UnixNano())
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	var (
+		calls int
+		fail  bool
+	)
+	h := Dedup(NewMemoryDedupStore(10, time.Hour))(HandlerFunc(func(ctx context.Context, data *Data) error {
+		calls++
+		if fail {
+			return errors.New("failed")
+		}
+		return nil
+	}))
+
+	ctx := context.Background()
+	event := &Data{EventData: &EventData{ID: "1"}}
+
+	fail = true
+	if err := h(ctx, event); err == nil {
+		t.Fatalf("Dedup() error = nil, want error")
+	}
+	fail = false
+	for i := 0; i < 2; i++ {
+		if err := h(ctx, event); err != nil {
+			t.Fatalf("Dedup() error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Dedup() calls = %v, want %v", calls, 2)
+	}
+
+	calls = 0
+	for i := 0; i < 2; i++ {
+		if err := h(ctx, &Data{}); err != nil {
+			t.Fatalf("Dedup() error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Dedup() calls without ID = %v, want %v", calls, 2)
+	}
+}
+
+func TestDedup_InFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := Dedup(NewMemoryDedupStore(10, 0))(HandlerFunc(func(ctx context.Context, data *Data) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	ctx := context.Background()
+	event := &Data{EventData: &EventData{ID: "1"}}
+
+	done := make(chan error)
+	go func() { done <- h(ctx, event) }()
+	<-started
+
+	if err := h(ctx, event); !errors.Is(err, ErrEventInFlight) {
+		t.Errorf("Dedup() error = %v, want %v", err, ErrEventInFlight)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Dedup() error = %v", err)
+	}
+}
+
+func TestMemoryDedupStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	s := NewMemoryDedupStore(2, time.Minute)
+	s.now = func() time.Time { return now }
+
+	seen := func(id string, want bool) {
+		t.Helper()
+		got, err := s.Seen(ctx, id)
+		if err != nil {
+			t.Fatalf("MemoryDedupStore.Seen() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("MemoryDedupStore.Seen(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	s.Mark(ctx, "1")
+	s.Mark(ctx, "2")
+	seen("1", true)
+	seen("2", true)
+
+	s.Mark(ctx, "3")
+	seen("1", false)
+	seen("3", true)
+
+	now = now.Add(time.Minute)
+	seen("2", false)
+	seen("3", false)
+	if got := s.Len(); got != 0 {
+		t.Errorf("MemoryDedupStore.Len() = %v, want %v", got, 0)
+	}
+}
+
+func TestFileDedupStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dedup.log")
+
+	s, err := OpenFileDedupStore(path, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenFileDedupStore() error = %v", err)
+	}
+	if err := s.Mark(ctx, "1"); err != nil {
+		t.Fatalf("FileDedupStore.Mark() error = %v", err)
+	}
+	if err := s.Mark(ctx, "bad\nid"); err == nil {
+		t.Errorf("FileDedupStore.Mark() error = nil, want error")
+	}
+	s.Close()
+
+	s, err = OpenFileDedupStore(path, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenFileDedupStore() error = %v", err)
+	}
+	defer s.Close()
+
+	if ok, _ := s.Seen(ctx, "1"); !ok {
+		t.Errorf("FileDedupStore.Seen() = false after reopen, want true")
+	}
+	if ok, _ := s.Seen(ctx, "2"); ok {
+		t.Errorf("FileDedupStore.Seen() = true, want false")
+	}
+}
+
+func TestFileDedupStore_Compact(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dedup")
+
+	s, err := OpenFileDedupStore(path, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenFileDedupStore() error = %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 3*minCompactLines; i++ {
+		if err := s.Mark(ctx, strconv.Itoa(i)); err != nil {
+			t.Fatalf("FileDedupStore.Mark() error = %v", err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(b, []byte("\n")); n > minCompactLines+1 {
+		t.Errorf("file has %d lines, want it compacted", n)
+	}
+	if ok, _ := s.Seen(ctx, strconv.Itoa(3*minCompactLines-1)); !ok {
+		t.Errorf("FileDedupStore.Seen() = false after compaction, want true")
+	}
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+)
+
+// Event is the typed form of an event, see Data.Event. It is one of the
+// *Event types of this package, the events of other types or actions are
+// an *UnknownEvent.
+type Event interface {
+	// Type returns the DataType and ActionType of the event.
+	Type() (DataType, ActionType)
+
+	event()
+}
+
+// CheckinEvent is a face recognized by a device.
+type CheckinEvent struct {
+	EventData
+	DeviceData
+	PersonData
+	PlaceData
+}
+
+// CheckinPictureEvent is a picture taken by a device at a check-in.
+type CheckinPictureEvent struct {
+	EventData
+	DeviceData
+	PersonData
+	PlaceData
+}
+
+type PersonAddedEvent struct {
+	EventData
+	PersonData
+	PlaceData
+}
+
+type PersonUpdatedEvent struct {
+	EventData
+	PersonData
+	PlaceData
+}
+
+type PersonDeletedEvent struct {
+	EventData
+	PersonData
+	PlaceData
+}
+
+type PlaceAddedEvent struct {
+	EventData
+	PlaceData
+}
+
+type PlaceUpdatedEvent struct {
+	EventData
+	PlaceData
+}
+
+type PlaceDeletedEvent struct {
+	EventData
+	PlaceData
+}
+
+type DeviceAddedEvent struct {
+	EventData
+	DeviceData
+	PlaceData
+}
+
+type DeviceUpdatedEvent struct {
+	EventData
+	DeviceData
+	PlaceData
+}
+
+type DeviceDeletedEvent struct {
+	EventData
+	DeviceData
+	PlaceData
+}
+
+// UnknownEvent is an event of a DataType or ActionType without a type in
+// this package, kept as its raw JSON.
+type UnknownEvent struct {
+	DataType   DataType
+	ActionType ActionType
+	Raw        json.RawMessage
+}
+
+func (e *CheckinEvent) Type() (DataType, ActionType) {
+	return DataLog, e.ActionType
+}
+
+func (e *CheckinPictureEvent) Type() (DataType, ActionType) {
+	return DataCheckinPicture, e.ActionType
+}
+
+func (*PersonAddedEvent) Type() (DataType, ActionType)   { return DataPerson, ActionAdd }
+func (*PersonUpdatedEvent) Type() (DataType, ActionType) { return DataPerson, ActionUpdate }
+func (*PersonDeletedEvent) Type() (DataType, ActionType) { return DataPerson, ActionDelete }
+func (*PlaceAddedEvent) Type() (DataType, ActionType)    { return DataPlace, ActionAdd }
+func (*PlaceUpdatedEvent) Type() (DataType, ActionType)  { return DataPlace, ActionUpdate }
+func (*PlaceDeletedEvent) Type() (DataType, ActionType)  { return DataPlace, ActionDelete }
+func (*DeviceAddedEvent) Type() (DataType, ActionType)   { return DataDevice, ActionAdd }
+func (*DeviceUpdatedEvent) Type() (DataType, ActionType) { return DataDevice, ActionUpdate }
+func (*DeviceDeletedEvent) Type() (DataType, ActionType) { return DataDevice, ActionDelete }
+
+func (e *UnknownEvent) Type() (DataType, ActionType) {
+	return e.DataType, e.ActionType
+}
+
+func (*CheckinEvent) event()        {}
+func (*CheckinPictureEvent) event() {}
+func (*PersonAddedEvent) event()    {}
+func (*PersonUpdatedEvent) event()  {}
+func (*PersonDeletedEvent) event()  {}
+func (*PlaceAddedEvent) event()     {}
+func (*PlaceUpdatedEvent) event()   {}
+func (*PlaceDeletedEvent) event()   {}
+func (*DeviceAddedEvent) event()    {}
+func (*DeviceUpdatedEvent) event()  {}
+func (*DeviceDeletedEvent) event()  {}
+func (*UnknownEvent) event()        {}
+
+// Event returns the typed event of data, according to its DataType and
+// ActionType:
+//
+//	switch e := data.Event().(type) {
+//	case *webhook.CheckinEvent:
+//		log.Printf("%s checked in at %s", e.PersonName, e.PlaceName)
+//	case *webhook.PersonDeletedEvent:
+//		log.Printf("%s was deleted", e.AliasID)
+//	}
+//
+// The Raw JSON of an UnknownEvent is RawData when it is set.
+func (d *Data) Event() Event {
+	var (
+		e      EventData
+		device DeviceData
+		person PersonData
+		place  PlaceData
+	)
+	if d.EventData != nil {
+		e = *d.EventData
+	}
+	if d.DeviceData != nil {
+		device = *d.DeviceData
+	}
+	if d.PersonData != nil {
+		person = *d.PersonData
+	}
+	if d.PlaceData != nil {
+		place = *d.PlaceData
+	}
+
+	switch d.DataType {
+	case DataLog:
+		return &CheckinEvent{e, device, person, place}
+	case DataCheckinPicture:
+		return &CheckinPictureEvent{e, device, person, place}
+	case DataPerson:
+		switch e.ActionType {
+		case ActionAdd:
+			return &PersonAddedEvent{e, person, place}
+		case ActionUpdate:
+			return &PersonUpdatedEvent{e, person, place}
+		case ActionDelete:
+			return &PersonDeletedEvent{e, person, place}
+		}
+	case DataPlace:
+		switch e.ActionType {
+		case ActionAdd:
+			return &PlaceAddedEvent{e, place}
+		case ActionUpdate:
+			return &PlaceUpdatedEvent{e, place}
+		case ActionDelete:
+			return &PlaceDeletedEvent{e, place}
+		}
+	case DataDevice:
+		switch e.ActionType {
+		case ActionAdd:
+			return &DeviceAddedEvent{e, device, place}
+		case ActionUpdate:
+			return &DeviceUpdatedEvent{e, device, place}
+		case ActionDelete:
+			return &DeviceDeletedEvent{e, device, place}
+		}
+	}
+
+	raw, _ := d.rawJSON()
+	return &UnknownEvent{
+		DataType:   d.DataType,
+		ActionType: e.ActionType,
+		Raw:        raw,
+	}
+}
+
+// rawJSON returns RawData, which is replaced by a reader of the same bytes
+// so it can be read again, or the JSON encoding of d without it.
+func (d *Data) rawJSON() ([]byte, error) {
+	if d.RawData == nil {
+		return json.Marshal(d)
+	}
+
+	b, err := ioutil.ReadAll(d.RawData)
+	if err != nil {
+		return nil, err
+	}
+	d.RawData = bytes.NewReader(b)
+	return b, nil
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestData_Event(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    Event
+	}{
+		{
+			name:    "checkin",
+			payload: `{"data_type":"log","action_type":"update","id":"1","aliasID":"A1","personName":"Nguyễn Văn A","personType":"0","deviceID":"C21024B155","placeID":"1542"}`,
+			want: &CheckinEvent{
+				EventData:  EventData{ActionType: ActionUpdate, ID: "1"},
+				DeviceData: DeviceData{DeviceID: "C21024B155"},
+				PersonData: PersonData{AliasID: "A1", PersonName: "Nguyễn Văn A", PersonType: PersonEmployee},
+				PlaceData:  PlaceData{PlaceID: 1542},
+			},
+		},
+		{
+			name:    "person deleted",
+			payload: `{"data_type":"person","action_type":"delete","id":"2","aliasID":"A1","placeID":1542}`,
+			want: &PersonDeletedEvent{
+				EventData:  EventData{ActionType: ActionDelete, ID: "2"},
+				PersonData: PersonData{AliasID: "A1"},
+				PlaceData:  PlaceData{PlaceID: 1542},
+			},
+		},
+		{
+			name:    "place added",
+			payload: `{"data_type":"place","action_type":"add","id":"3","placeID":1542,"placeName":"Hanet HQ"}`,
+			want: &PlaceAddedEvent{
+				EventData: EventData{ActionType: ActionAdd, ID: "3"},
+				PlaceData: PlaceData{PlaceID: 1542, PlaceName: "Hanet HQ"},
+			},
+		},
+		{
+			name:    "device updated",
+			payload: `{"data_type":"device","action_type":"update","id":"4","deviceID":"C21024B155","deviceName":"Front door"}`,
+			want: &DeviceUpdatedEvent{
+				EventData:  EventData{ActionType: ActionUpdate, ID: "4"},
+				DeviceData: DeviceData{DeviceID: "C21024B155", DeviceName: "Front door"},
+			},
+		},
+		{
+			name:    "unknown action",
+			payload: `{"data_type":"device","action_type":"reboot","id":"5","uptime":12}`,
+			want: &UnknownEvent{
+				DataType:   DataDevice,
+				ActionType: "reboot",
+				Raw:        json.RawMessage(`{"data_type":"device","action_type":"reboot","id":"5","uptime":12}`),
+			},
+		},
+		{
+			name:    "unknown data type",
+			payload: `{"data_type":"door","state":"open"}`,
+			want: &UnknownEvent{
+				DataType: "door",
+				Raw:      json.RawMessage(`{"data_type":"door","state":"open"}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data Data
+			if err := json.Unmarshal([]byte(tt.payload), &data); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			data.RawData = strings.NewReader(tt.payload)
+
+			got := data.Event()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Data.Event() = %#v, want %#v", got, tt.want)
+			}
+
+			gotData, gotAction := got.Type()
+			if gotData != data.DataType {
+				t.Errorf("Event.Type() = %v, want %v", gotData, data.DataType)
+			}
+			if data.EventData != nil && gotAction != data.ActionType {
+				t.Errorf("Event.Type() = %v, want %v", gotAction, data.ActionType)
+			}
+		})
+	}
+}
+
+func TestData_Event_WithoutJSON(t *testing.T) {
+	data := &Data{DataType: "door"}
+
+	e, ok := data.Event().(*UnknownEvent)
+	if !ok {
+		t.Fatalf("Data.Event() = %T, want *UnknownEvent", data.Event())
+	}
+	if string(e.Raw) != `{"data_type":"door"}` {
+		t.Errorf("UnknownEvent.Raw = %s, want %s", e.Raw, `{"data_type":"door"}`)
+	}
+}
+
+func TestData_Embedded(t *testing.T) {
+	var got struct {
+		Data
+		Received string `json:"received"`
+	}
+	if err := json.Unmarshal([]byte(`{"data_type":"log","id":"1","received":"mqtt"}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.DataType != DataLog || got.ID != "1" || got.Received != "mqtt" {
+		t.Errorf("json.Unmarshal() = %+v, want the fields of both structs", got)
+	}
+}
@@ -0,0 +1,382 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// Message is an event forwarded to the sinks.
+type Message struct {
+	ID       string
+	DataType DataType
+
+	// Payload is the JSON of the event, as received when it is known.
+	Payload []byte
+}
+
+// Sink is a destination of the events forwarded by Forward.
+type Sink interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// SinkFunc is an adapter to use a function as a Sink.
+type SinkFunc func(ctx context.Context, m *Message) error
+
+func (f SinkFunc) Send(ctx context.Context, m *Message) error {
+	return f(ctx, m)
+}
+
+// SinkError is the failure of a sink to receive an event.
+type SinkError struct {
+	Sink string
+	ID   string
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return fmt.Sprintf("webhook: forwarding event %s to %s: %v", e.ID, e.Sink, e.Err)
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+type ForwardOptions struct {
+	// Backoff is the delay before sending to a sink again, doubled after
+	// each failure.
+	Backoff time.Duration
+
+	MaxAttempts int
+
+	// OnError reports the sinks that failed to receive an event, after
+	// their last attempt.
+	OnError func(context.Context, error)
+
+	// Timeout limits each attempt to send to a sink.
+	Timeout time.Duration
+}
+
+type ForwardOption = func(*ForwardOptions)
+
+func WithForwardBackoff(d time.Duration) ForwardOption {
+	return func(o *ForwardOptions) {
+		o.Backoff = d
+	}
+}
+
+func WithForwardMaxAttempts(n int) ForwardOption {
+	return func(o *ForwardOptions) {
+		o.MaxAttempts = n
+	}
+}
+
+func WithForwardOnError(fn func(context.Context, error)) ForwardOption {
+	return func(o *ForwardOptions) {
+		o.OnError = fn
+	}
+}
+
+func WithForwardTimeout(d time.Duration) ForwardOption {
+	return func(o *ForwardOptions) {
+		o.Timeout = d
+	}
+}
+
+// Forward sends the events to all the sinks at the same time, each with its
+// own retries, so a failing sink doesn't stop the others. The payload is
+// the raw JSON of the event when Data.RawData is set, otherwise its JSON
+// encoding.
+//
+// The event is handled once every sink received it or gave up, the sinks
+// that gave up are reported to OnError. The event fails only when no sink
+// received it, with the error of the first sink, so Hanet delivering it
+// again doesn't duplicate it in the other sinks.
+//
+// The defaults of 2 attempts of at most 2s each keep Hanet waiting for
+// about 4s at worst. For longer retries, wrap the handler with NewQueue or
+// OpenSpool, which answer Hanet without waiting for the sinks.
+func Forward(sinks map[string]Sink, opts ...ForwardOption) HandlerFunc {
+	o := &ForwardOptions{
+		Backoff:     200 * time.Millisecond,
+		MaxAttempts: 2,
+		Timeout:     2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	names := make([]string, 0, len(sinks))
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(ctx context.Context, data *Data) error {
+		m, err := newMessage(data)
+		if err != nil {
+			return err
+		}
+
+		errs := make([]error, len(names))
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				errs[i] = forward(ctx, o, name, sinks[name], m)
+			}(i, name)
+		}
+		wg.Wait()
+
+		var failed []error
+		for _, err := range errs {
+			if err != nil {
+				failed = append(failed, err)
+			}
+		}
+
+		// Without any sink receiving it, the event can fail so it is
+		// delivered again.
+		if len(failed) > 0 && len(failed) == len(names) {
+			err, failed = failed[0], failed[1:]
+		}
+		if o.OnError != nil {
+			for _, ferr := range failed {
+				o.OnError(ctx, ferr)
+			}
+		}
+		return err
+	}
+}
+
+func newMessage(data *Data) (*Message, error) {
+	m := &Message{DataType: data.DataType}
+	if data.EventData != nil {
+		m.ID = data.ID
+	}
+
+	b, err := data.rawJSON()
+	if err != nil {
+		return nil, err
+	}
+	m.Payload = b
+	return m, nil
+}
+
+func forward(ctx context.Context, o *ForwardOptions, name string, s Sink, m *Message) (err error) {
+	defer func() {
+		status := "ok"
+		if err != nil {
+			status = "failed"
+		}
+		stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(keySink, name), tag.Upsert(keyStatus, status)},
+			mForwarded.M(1))
+	}()
+
+	backoff := o.Backoff
+	for attempt := 1; ; attempt++ {
+		err = sendOnce(ctx, o.Timeout, s, m)
+		if err == nil {
+			return nil
+		}
+
+		var perm errPermanent
+		if errors.As(err, &perm) {
+			return &SinkError{Sink: name, ID: m.ID, Err: perm.error}
+		}
+		if attempt >= o.MaxAttempts || ctx.Err() != nil {
+			return &SinkError{Sink: name, ID: m.ID, Err: err}
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return &SinkError{Sink: name, ID: m.ID, Err: err}
+		}
+		backoff *= 2
+	}
+}
+
+func sendOnce(ctx context.Context, timeout time.Duration, s Sink, m *Message) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return s.Send(ctx, m)
+}
+
+// SignatureHeader is the header of the HMAC signature of the events posted
+// by HTTPSink, see SignPayload.
+const SignatureHeader = "X-Hanet-Signature"
+
+// SignPayload returns the signature of a payload posted by HTTPSink,
+// "sha256=" followed by the hex HMAC-SHA256 of the payload with secret.
+func SignPayload(secret, payload []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// HTTPSink posts the events to a URL, signed with Secret in the
+// SignatureHeader when it is set. The event ID is in the X-Hanet-Event-ID
+// header.
+type HTTPSink struct {
+	URL    string
+	Secret []byte
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+var _ Sink = (*HTTPSink)(nil)
+
+func (s *HTTPSink) Send(ctx context.Context, m *Message) error {
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(m.Payload))
+	if err != nil {
+		return errPermanent{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hanet-Event-ID", m.ID)
+	if s.Secret != nil {
+		req.Header.Set(SignatureHeader, SignPayload(s.Secret, m.Payload))
+	}
+
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("POST %s: %s", s.URL, resp.Status)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return err
+		}
+		return errPermanent{err}
+	}
+	return nil
+}
+
+// NATSPublisher publishes messages to NATS, it is implemented by *nats.Conn.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSSink publishes the events to a NATS subject.
+type NATSSink struct {
+	Conn    NATSPublisher
+	Subject string
+}
+
+var _ Sink = (*NATSSink)(nil)
+
+func (s *NATSSink) Send(ctx context.Context, m *Message) error {
+	return s.Conn.Publish(s.Subject, m.Payload)
+}
+
+// KafkaProducer writes messages to Kafka. With kafka-go, it is:
+//
+//	webhook.KafkaProducerFunc(func(ctx context.Context, topic string, key, value []byte) error {
+//		return w.WriteMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: value})
+//	})
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaProducerFunc is an adapter to use a function as a KafkaProducer.
+type KafkaProducerFunc func(ctx context.Context, topic string, key, value []byte) error
+
+func (f KafkaProducerFunc) Produce(ctx context.Context, topic string, key, value []byte) error {
+	return f(ctx, topic, key, value)
+}
+
+// KafkaSink writes the events to a Kafka topic, keyed by event ID.
+type KafkaSink struct {
+	Producer KafkaProducer
+	Topic    string
+}
+
+var _ Sink = (*KafkaSink)(nil)
+
+func (s *KafkaSink) Send(ctx context.Context, m *Message) error {
+	return s.Producer.Produce(ctx, s.Topic, []byte(m.ID), m.Payload)
+}
+
+// FileSink appends the events to a JSONL file, one event per line.
+type FileSink struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+}
+
+var _ Sink = (*FileSink)(nil)
+
+// OpenFileSink opens the file to append the events, creating it when
+// needed.
+func OpenFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (s *FileSink) Send(ctx context.Context, m *Message) error {
+	var line bytes.Buffer
+	if err := json.Compact(&line, m.Payload); err != nil {
+		return errPermanent{err}
+	}
+	line.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return os.ErrClosed
+	}
+	if _, err := s.w.Write(line.Bytes()); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	err := s.w.Flush()
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.f = nil
+	return err
+}
@@ -0,0 +1,321 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// natsServer is a stand-in of a NATS server, speaking enough of the client
+// protocol to receive the published messages.
+type natsServer struct {
+	ln net.Listener
+
+	mu   sync.Mutex
+	msgs map[string][][]byte
+}
+
+func newNATSServer(t *testing.T) *natsServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &natsServer{ln: ln, msgs: map[string][][]byte{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *natsServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		case "PUB":
+			// PUB <subject> [reply-to] <size>
+			n, err := strconv.Atoi(args[len(args)-1])
+			if err != nil {
+				fmt.Fprintf(conn, "-ERR 'Unknown Protocol Operation'\r\n")
+				return
+			}
+			b := make([]byte, n+2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.msgs[args[1]] = append(s.msgs[args[1]], b[:n])
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *natsServer) published(subject string) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.msgs[subject]
+}
+
+// natsConn is a minimal NATS client, flushing each message like
+// (*nats.Conn).Publish followed by Flush.
+type natsConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialNATS(t *testing.T, addr string) *natsConn {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &natsConn{conn: conn, r: bufio.NewReader(conn)}
+	if line, err := c.r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "INFO ") {
+		t.Fatalf("NATS handshake = %q, %v", line, err)
+	}
+	fmt.Fprintf(conn, "CONNECT {\"verbose\":false,\"pedantic\":false}\r\n")
+	return c
+}
+
+func (c *natsConn) Publish(subject string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(c.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data); err != nil {
+		return err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(line) != "PONG" {
+		return fmt.Errorf("nats: %s", strings.TrimSpace(line))
+	}
+	return nil
+}
+
+func TestForward(t *testing.T) {
+	secret := []byte("s3cr3t")
+	var posted [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if got, want := r.Header.Get(SignatureHeader), SignPayload(secret, b); got != want {
+			t.Errorf("%s = %v, want %v", SignatureHeader, got, want)
+		}
+		if got := r.Header.Get("X-Hanet-Event-ID"); got != "1" {
+			t.Errorf("X-Hanet-Event-ID = %v, want 1", got)
+		}
+		posted = append(posted, b)
+	}))
+	defer srv.Close()
+
+	ns := newNATSServer(t)
+	nc := dialNATS(t, ns.ln.Addr().String())
+	type kafkaMsg struct{ topic, key, value string }
+	var produced []kafkaMsg
+	file := filepath.Join(t.TempDir(), "events.jsonl")
+	fs, err := OpenFileSink(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	var flaky int32
+	var reported []error
+	h := Forward(map[string]Sink{
+		"http": &HTTPSink{URL: srv.URL, Secret: secret},
+		"nats": &NATSSink{Conn: nc, Subject: "hanet.events"},
+		"kafka": &KafkaSink{Topic: "hanet-events", Producer: KafkaProducerFunc(func(ctx context.Context, topic string, key, value []byte) error {
+			produced = append(produced, kafkaMsg{topic, string(key), string(value)})
+			return nil
+		})},
+		"file": fs,
+		"flaky": SinkFunc(func(context.Context, *Message) error {
+			if atomic.AddInt32(&flaky, 1) < 3 {
+				return errors.New("unavailable")
+			}
+			return nil
+		}),
+		"down": SinkFunc(func(context.Context, *Message) error {
+			return errors.New("unavailable")
+		}),
+	}, WithForwardBackoff(time.Millisecond), WithForwardMaxAttempts(3), WithForwardOnError(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	}))
+
+	payload := []byte(`{"data_type": "log", "id": "1", "extra": true}`)
+	data := &Data{
+		RawData:   bytes.NewReader(payload),
+		DataType:  DataLog,
+		EventData: &EventData{ID: "1"},
+	}
+	if err := h.ServeWebhook(context.Background(), data); err != nil {
+		t.Fatalf("ServeWebhook() error = %v", err)
+	}
+
+	if len(posted) != 1 || !bytes.Equal(posted[0], payload) {
+		t.Errorf("posted = %q, want %q", posted, payload)
+	}
+	if msgs := ns.published("hanet.events"); len(msgs) != 1 || !bytes.Equal(msgs[0], payload) {
+		t.Errorf("published = %q, want %q", msgs, payload)
+	}
+	if want := (kafkaMsg{"hanet-events", "1", string(payload)}); len(produced) != 1 || produced[0] != want {
+		t.Errorf("produced = %v, want %v", produced, want)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != `{"data_type":"log","id":"1","extra":true}`+"\n" {
+		t.Errorf("file = %q", b)
+	}
+	if flaky != 3 {
+		t.Errorf("flaky sink attempts = %d, want 3", flaky)
+	}
+
+	var serr *SinkError
+	if len(reported) != 1 || !errors.As(reported[0], &serr) || serr.Sink != "down" || serr.ID != "1" {
+		t.Errorf("reported = %v, want the down sink", reported)
+	}
+
+	// The payload is still readable by the next handlers.
+	if b, _ := ioutil.ReadAll(data.RawData); !bytes.Equal(b, payload) {
+		t.Errorf("RawData = %q, want %q", b, payload)
+	}
+}
+
+func TestForward_Fails(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	h := Forward(map[string]Sink{
+		"http": &HTTPSink{URL: srv.URL},
+	}, WithForwardBackoff(time.Millisecond))
+
+	err := h.ServeWebhook(context.Background(), &Data{DataType: DataLog, EventData: &EventData{ID: "1"}})
+	var serr *SinkError
+	if !errors.As(err, &serr) || serr.Sink != "http" {
+		t.Fatalf("ServeWebhook() error = %v, want SinkError", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 as a 400 is not retried", calls)
+	}
+}
+
+func TestForward_Isolation(t *testing.T) {
+	ok := SinkFunc(func(context.Context, *Message) error { return nil })
+	down := SinkFunc(func(context.Context, *Message) error { return errors.New("unavailable") })
+	data := func() *Data {
+		return &Data{DataType: DataLog, EventData: &EventData{ID: "1"}}
+	}
+
+	// Without OnError, a failing sink doesn't fail the event either, as it
+	// would be delivered again to the sinks that received it.
+	h := Forward(map[string]Sink{"a": ok, "b": down}, WithForwardBackoff(time.Millisecond))
+	if err := h.ServeWebhook(context.Background(), data()); err != nil {
+		t.Errorf("ServeWebhook() error = %v, want nil as a sink received the event", err)
+	}
+
+	var reported []error
+	h = Forward(map[string]Sink{"a": down, "b": down}, WithForwardBackoff(time.Millisecond),
+		WithForwardOnError(func(ctx context.Context, err error) {
+			reported = append(reported, err)
+		}))
+	err := h.ServeWebhook(context.Background(), data())
+	var serr *SinkError
+	if !errors.As(err, &serr) || serr.Sink != "a" {
+		t.Errorf("ServeWebhook() error = %v, want the error of sink a", err)
+	}
+	if len(reported) != 1 || !errors.As(reported[0], &serr) || serr.Sink != "b" {
+		t.Errorf("reported = %v, want the error of sink b", reported)
+	}
+}
+
+func TestForward_Payload(t *testing.T) {
+	var got []byte
+	h := Forward(map[string]Sink{
+		"sink": SinkFunc(func(ctx context.Context, m *Message) error {
+			got = m.Payload
+			return nil
+		}),
+	})
+
+	data := &Data{DataType: DataPlace, EventData: &EventData{ID: "1", ActionType: ActionAdd}}
+	if err := h.ServeWebhook(context.Background(), data); err != nil {
+		t.Fatal(err)
+	}
+	var d Data
+	if err := json.Unmarshal(got, &d); err != nil || d.DataType != DataPlace || d.ID != "1" {
+		t.Errorf("payload = %s, want the JSON of the event", got)
+	}
+}
+
+func TestFileSink_Close(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.jsonl")
+	s, err := OpenFileSink(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{`{"id":"1"}`, "{\n\"id\": \"2\"\n}"} {
+		if err := s.Send(context.Background(), &Message{Payload: []byte(p)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(context.Background(), &Message{Payload: []byte(`{}`)}); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Send() after Close error = %v, want %v", err, os.ErrClosed)
+	}
+
+	f, _ := os.Open(file)
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 2 || lines[1] != `{"id":"2"}` {
+		t.Errorf("lines = %q", lines)
+	}
+}
@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+)
+
+// Middleware wraps a Handler, ReportStats is a Middleware.
+type Middleware func(Handler) HandlerFunc
+
+// Chain wraps h with the middlewares, the first one is the outermost.
+func Chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+type muxKey struct {
+	dataType   DataType
+	actionType ActionType
+}
+
+// Mux dispatches the events to the handler registered for their DataType
+// and ActionType. Events without a matching handler go to the fallback
+// handler, or are ignored when there is none.
+//
+//	mux := webhook.NewMux()
+//	mux.Use(webhook.ReportStats)
+//	mux.HandleFunc(webhook.DataCheckinPicture, onCheckin)
+//	mux.HandleActionFunc(webhook.DataPerson, webhook.ActionDelete, onPersonDeleted)
+//	http.Handle("/webhook", webhook.NewHTTPHandler(mux))
+//
+// The middlewares are applied once per handler, when it is registered or
+// when Use is called, not for each event.
+type Mux struct {
+	mu          sync.RWMutex
+	handlers    map[muxKey]Handler
+	fallback    Handler
+	middlewares []Middleware
+
+	// chained are the handlers wrapped with the middlewares.
+	chained         map[muxKey]Handler
+	chainedFallback Handler
+}
+
+var _ Handler = (*Mux)(nil)
+
+func NewMux() *Mux {
+	return &Mux{
+		handlers: map[muxKey]Handler{},
+		chained:  map[muxKey]Handler{},
+	}
+}
+
+// Handle registers the handler for every action of the data type.
+func (m *Mux) Handle(dataType DataType, h Handler) {
+	m.HandleAction(dataType, "", h)
+}
+
+func (m *Mux) HandleFunc(dataType DataType, fn func(context.Context, *Data) error) {
+	m.Handle(dataType, HandlerFunc(fn))
+}
+
+// HandleAction registers the handler for an action of the data type, it takes
+// precedence over the handler of the whole data type.
+func (m *Mux) HandleAction(dataType DataType, actionType ActionType, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := muxKey{dataType, actionType}
+	m.handlers[k] = h
+	m.chained[k] = Chain(h, m.middlewares...)
+}
+
+func (m *Mux) HandleActionFunc(dataType DataType, actionType ActionType, fn func(context.Context, *Data) error) {
+	m.HandleAction(dataType, actionType, HandlerFunc(fn))
+}
+
+// Fallback registers the handler of the events without a matching handler.
+func (m *Mux) Fallback(h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fallback = h
+	m.chainedFallback = nil
+	if h != nil {
+		m.chainedFallback = Chain(h, m.middlewares...)
+	}
+}
+
+// Use appends middlewares wrapping every handler of the mux, including the
+// fallback handler.
+func (m *Mux) Use(mws ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.middlewares = append(m.middlewares, mws...)
+	for k, h := range m.handlers {
+		m.chained[k] = Chain(h, m.middlewares...)
+	}
+	if m.fallback != nil {
+		m.chainedFallback = Chain(m.fallback, m.middlewares...)
+	}
+}
+
+// Handler returns the handler of the event, or nil if none matches.
+func (m *Mux) Handler(data *Data) Handler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return lookup(m.handlers, m.fallback, data)
+}
+
+func (m *Mux) ServeWebhook(ctx context.Context, data *Data) error {
+	m.mu.RLock()
+	h := lookup(m.chained, m.chainedFallback, data)
+	m.mu.RUnlock()
+
+	if h == nil {
+		return nil
+	}
+	return h.ServeWebhook(ctx, data)
+}
+
+func lookup(handlers map[muxKey]Handler, fallback Handler, data *Data) Handler {
+	var actionType ActionType
+	if data.EventData != nil {
+		actionType = data.ActionType
+	}
+
+	if h, ok := handlers[muxKey{data.DataType, actionType}]; ok {
+		return h
+	}
+	if h, ok := handlers[muxKey{data.DataType, ""}]; ok {
+		return h
+	}
+	return fallback
+}
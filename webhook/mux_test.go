@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMux_ServeWebhook(t *testing.T) {
+	var got []string
+	record := func(name string) HandlerFunc {
+		return func(ctx context.Context, data *Data) error {
+			got = append(got, name)
+			return nil
+		}
+	}
+	tag := func(name string) Middleware {
+		return func(next Handler) HandlerFunc {
+			return func(ctx context.Context, data *Data) error {
+				got = append(got, name)
+				return next.ServeWebhook(ctx, data)
+			}
+		}
+	}
+
+	m := NewMux()
+	m.Use(tag("outer"), tag("inner"))
+	m.Handle(DataPerson, record("person"))
+	m.HandleAction(DataPerson, ActionDelete, record("person-delete"))
+	m.Handle(DataCheckinPicture, Chain(record("checkin"), tag("route")))
+
+	tests := []struct {
+		name     string
+		fallback Handler
+		data     *Data
+		want     []string
+	}{
+		{
+			name: "data type",
+			data: &Data{DataType: DataPerson, EventData: &EventData{ActionType: ActionAdd}},
+			want: []string{"outer", "inner", "person"},
+		},
+		{
+			name: "action type takes precedence",
+			data: &Data{DataType: DataPerson, EventData: &EventData{ActionType: ActionDelete}},
+			want: []string{"outer", "inner", "person-delete"},
+		},
+		{
+			name: "no event data",
+			data: &Data{DataType: DataPerson},
+			want: []string{"outer", "inner", "person"},
+		},
+		{
+			name: "route middleware",
+			data: &Data{DataType: DataCheckinPicture},
+			want: []string{"outer", "inner", "route", "checkin"},
+		},
+		{
+			name: "unmatched without fallback",
+			data: &Data{DataType: DataDevice},
+		},
+		{
+			name:     "unmatched with fallback",
+			fallback: record("fallback"),
+			data:     &Data{DataType: DataDevice},
+			want:     []string{"outer", "inner", "fallback"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			m.Fallback(tt.fallback)
+
+			if err := m.ServeWebhook(context.Background(), tt.data); err != nil {
+				t.Errorf("Mux.ServeWebhook() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mux.ServeWebhook() calls = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMux_UseReportStats(t *testing.T) {
+	m := NewMux()
+	m.Use(ReportStats)
+
+	called := false
+	m.HandleFunc(DataCheckinPicture, func(ctx context.Context, data *Data) error {
+		called = true
+		return nil
+	})
+
+	err := m.ServeWebhook(context.Background(), &Data{
+		DataType:   DataCheckinPicture,
+		EventData:  &EventData{ActionType: ActionAdd},
+		PersonData: &PersonData{PersonType: PersonEmployee},
+	})
+	if err != nil || !called {
+		t.Errorf("Mux.ServeWebhook() error = %v, called %v", err, called)
+	}
+}
+
+func TestMux_UseBuildsChainOnce(t *testing.T) {
+	m := NewMux()
+
+	built := 0
+	m.Use(func(h Handler) HandlerFunc {
+		built++
+		return h.ServeWebhook
+	})
+	m.HandleFunc(DataLog, func(context.Context, *Data) error { return nil })
+	m.Fallback(HandlerFunc(func(context.Context, *Data) error { return nil }))
+
+	for i := 0; i < 3; i++ {
+		m.ServeWebhook(context.Background(), &Data{DataType: DataLog})
+		m.ServeWebhook(context.Background(), &Data{DataType: DataPlace})
+	}
+	if built != 2 {
+		t.Errorf("middleware built %d times, want 2", built)
+	}
+}
@@ -0,0 +1,229 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opencensus.io/stats"
+)
+
+var (
+	// ErrQueueFull is returned by a Queue with the QueueReject policy when
+	// it is full, NewHTTPHandler replies 503 so Hanet delivers it later.
+	ErrQueueFull = errors.New("webhook: queue is full")
+
+	// ErrQueueClosed is returned by a Queue after Shutdown.
+	ErrQueueClosed = errors.New("webhook: queue is closed")
+
+	// ErrEventDropped is reported to OnError for the events dropped by the
+	// QueueDropOldest policy.
+	ErrEventDropped = errors.New("webhook: event dropped from the queue")
+)
+
+// QueuePolicy decides what a full Queue does with a new event.
+type QueuePolicy int
+
+const (
+	// QueueBlock waits for room in the queue, or for the request to be
+	// canceled.
+	QueueBlock QueuePolicy = iota
+
+	// QueueDropOldest drops the oldest queued event to make room.
+	QueueDropOldest
+
+	// QueueReject rejects the new event with ErrQueueFull.
+	QueueReject
+)
+
+type QueueOptions struct {
+	OnError func(context.Context, error)
+	Policy  QueuePolicy
+	Size    int
+	Workers int
+}
+
+type QueueOption = func(*QueueOptions)
+
+func WithQueuePolicy(p QueuePolicy) QueueOption {
+	return func(o *QueueOptions) {
+		o.Policy = p
+	}
+}
+
+// WithQueueSize sets the number of events waiting for a worker, at least 1.
+func WithQueueSize(n int) QueueOption {
+	return func(o *QueueOptions) {
+		o.Size = n
+	}
+}
+
+func WithQueueWorkers(n int) QueueOption {
+	return func(o *QueueOptions) {
+		o.Workers = n
+	}
+}
+
+func WithQueueOnError(fn func(context.Context, error)) QueueOption {
+	return func(o *QueueOptions) {
+		o.OnError = fn
+	}
+}
+
+// Queue is a Handler queueing the events to be handled by a pool of workers,
+// so NewHTTPHandler acknowledges them without waiting for the handler:
+//
+//	q := webhook.NewQueue(h, webhook.WithQueuePolicy(webhook.QueueReject))
+//	http.Handle("/webhook", webhook.NewHTTPHandler(q, webhook.WithSecretVerify(nil)))
+//	...
+//	q.Shutdown(ctx)
+//
+// The errors of the handler are reported to OnError.
+type Queue struct {
+	fn Handler
+	o  *QueueOptions
+
+	mu     sync.RWMutex
+	closed bool
+	events chan *Data
+	done   chan struct{}
+}
+
+var _ Handler = (*Queue)(nil)
+
+// NewQueue starts the workers of a queue handling the events with fn. It
+// defaults to a queue of 100 events handled by 4 workers, blocking when it
+// is full.
+func NewQueue(fn Handler, opts ...QueueOption) *Queue {
+	o := &QueueOptions{
+		Policy:  QueueBlock,
+		Size:    100,
+		Workers: 4,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Workers < 1 {
+		o.Workers = 1
+	}
+	if o.Size < 1 {
+		// An unbuffered queue has no oldest event to drop.
+		o.Size = 1
+	}
+
+	q := &Queue{
+		fn:     fn,
+		o:      o,
+		events: make(chan *Data, o.Size),
+		done:   make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(o.Workers)
+	for i := 0; i < o.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			q.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(q.done)
+	}()
+
+	return q
+}
+
+// ServeWebhook queues the event according to the policy of the queue.
+func (q *Queue) ServeWebhook(ctx context.Context, data *Data) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	defer q.recordDepth()
+
+	switch q.o.Policy {
+	case QueueReject:
+		select {
+		case q.events <- data:
+			return nil
+		default:
+			stats.Record(ctx, mQueueRejected.M(1))
+			return ErrQueueFull
+		}
+	case QueueDropOldest:
+		for {
+			select {
+			case q.events <- data:
+				return nil
+			default:
+			}
+
+			select {
+			case old := <-q.events:
+				stats.Record(ctx, mQueueDropped.M(1))
+				if old.EventData != nil {
+					q.onError(fmt.Errorf("%w: %s", ErrEventDropped, old.ID))
+				} else {
+					q.onError(ErrEventDropped)
+				}
+			default:
+			}
+		}
+	default:
+		select {
+		case q.events <- data:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Len returns the number of events waiting in the queue.
+func (q *Queue) Len() int {
+	return len(q.events)
+}
+
+// Shutdown stops accepting events and waits for the queued events to be
+// handled, or for ctx to be done.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	for data := range q.events {
+		q.recordDepth()
+
+		// The request is done already, so the event is handled without
+		// its context.
+		if err := q.fn.ServeWebhook(context.Background(), data); err != nil {
+			q.onError(err)
+		}
+	}
+}
+
+func (q *Queue) onError(err error) {
+	if q.o.OnError != nil {
+		q.o.OnError(context.Background(), err)
+	}
+}
+
+func (q *Queue) recordDepth() {
+	stats.Record(context.Background(), mQueueDepth.M(int64(len(q.events))))
+}
@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func event(id string) *Data {
+	return &Data{EventData: &EventData{ID: id}}
+}
+
+func TestQueue(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	q := NewQueue(HandlerFunc(func(ctx context.Context, data *Data) error {
+		mu.Lock()
+		got = append(got, data.ID)
+		mu.Unlock()
+		return nil
+	}), WithQueueSize(2), WithQueueWorkers(3))
+
+	ctx := context.Background()
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		if err := q.ServeWebhook(ctx, event(id)); err != nil {
+			t.Fatalf("Queue.ServeWebhook() error = %v", err)
+		}
+	}
+	if err := q.Shutdown(ctx); err != nil {
+		t.Fatalf("Queue.Shutdown() error = %v", err)
+	}
+	if len(got) != 5 {
+		t.Errorf("Queue handled %v, want 5 events", got)
+	}
+
+	if err := q.ServeWebhook(ctx, event("6")); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Queue.ServeWebhook() error = %v, want %v", err, ErrQueueClosed)
+	}
+}
+
+// newBlockedQueue returns a queue whose single worker is handling the event
+// "0" until release is closed.
+func newBlockedQueue(t *testing.T, opts ...QueueOption) (q *Queue, got func() []string, release func()) {
+	var (
+		mu      sync.Mutex
+		handled []string
+		started = make(chan struct{})
+		wait    = make(chan struct{})
+	)
+	opts = append([]QueueOption{WithQueueSize(2), WithQueueWorkers(1)}, opts...)
+	q = NewQueue(HandlerFunc(func(ctx context.Context, data *Data) error {
+		if data.ID == "0" {
+			close(started)
+			<-wait
+		}
+		mu.Lock()
+		handled = append(handled, data.ID)
+		mu.Unlock()
+		return nil
+	}), opts...)
+
+	if err := q.ServeWebhook(context.Background(), event("0")); err != nil {
+		t.Fatalf("Queue.ServeWebhook() error = %v", err)
+	}
+	<-started
+
+	got = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled
+	}
+	return q, got, func() { close(wait) }
+}
+
+func TestQueue_Policy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reject", func(t *testing.T) {
+		q, _, release := newBlockedQueue(t, WithQueuePolicy(QueueReject))
+		q.ServeWebhook(ctx, event("1"))
+		q.ServeWebhook(ctx, event("2"))
+		if err := q.ServeWebhook(ctx, event("3")); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("Queue.ServeWebhook() error = %v, want %v", err, ErrQueueFull)
+		}
+		release()
+		q.Shutdown(ctx)
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		var dropped []error
+		q, got, release := newBlockedQueue(t, WithQueuePolicy(QueueDropOldest),
+			WithQueueOnError(func(ctx context.Context, err error) {
+				dropped = append(dropped, err)
+			}))
+		for _, id := range []string{"1", "2", "3"} {
+			if err := q.ServeWebhook(ctx, event(id)); err != nil {
+				t.Fatalf("Queue.ServeWebhook() error = %v", err)
+			}
+		}
+		release()
+		q.Shutdown(ctx)
+
+		if s := strings.Join(got(), ","); s != "0,2,3" {
+			t.Errorf("Queue handled %v, want 0,2,3", s)
+		}
+		if len(dropped) != 1 || !errors.Is(dropped[0], ErrEventDropped) {
+			t.Errorf("Queue OnError = %v, want %v", dropped, ErrEventDropped)
+		}
+	})
+
+	t.Run("drop oldest unbuffered", func(t *testing.T) {
+		q, got, release := newBlockedQueue(t, WithQueuePolicy(QueueDropOldest), WithQueueSize(0))
+		for _, id := range []string{"1", "2"} {
+			if err := q.ServeWebhook(ctx, event(id)); err != nil {
+				t.Fatalf("Queue.ServeWebhook() error = %v", err)
+			}
+		}
+		release()
+		q.Shutdown(ctx)
+
+		if s := strings.Join(got(), ","); s != "0,2" {
+			t.Errorf("Queue handled %v, want 0,2", s)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		q, _, release := newBlockedQueue(t)
+		q.ServeWebhook(ctx, event("1"))
+		q.ServeWebhook(ctx, event("2"))
+
+		cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if err := q.ServeWebhook(cctx, event("3")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Queue.ServeWebhook() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		release()
+		q.Shutdown(ctx)
+	})
+}
+
+func TestQueue_ShutdownTimeout(t *testing.T) {
+	q, _, release := newBlockedQueue(t)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Queue.Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNewHTTPHandler_QueueFull(t *testing.T) {
+	q, _, release := newBlockedQueue(t, WithQueuePolicy(QueueReject), WithQueueSize(1))
+	defer func() {
+		release()
+		q.Shutdown(context.Background())
+	}()
+	q.ServeWebhook(context.Background(), event("1"))
+
+	w := httptest.NewRecorder()
+	NewHTTPHandler(q)(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"1"}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("NewHTTPHandler() code = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"crypto/md5"
+	"sync/atomic"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// Secret is a client secret signing the events, the name identifies it in
+// the metrics without revealing it.
+type Secret struct {
+	Name  string
+	Value []byte
+}
+
+// SecretProvider returns the secrets accepted when verifying the events.
+type SecretProvider interface {
+	Secrets() []Secret
+}
+
+// SecretSet is a SecretProvider whose secrets can be swapped at runtime, e.g.
+// to add the new secret before rotating it and remove the old one after.
+type SecretSet struct {
+	v atomic.Value
+}
+
+var _ SecretProvider = (*SecretSet)(nil)
+
+func NewSecretSet(secrets ...Secret) *SecretSet {
+	s := &SecretSet{}
+	s.Store(secrets...)
+	return s
+}
+
+// Store replaces the secrets of the set.
+func (s *SecretSet) Store(secrets ...Secret) {
+	s.v.Store(append([]Secret(nil), secrets...))
+}
+
+func (s *SecretSet) Secrets() []Secret {
+	secrets, _ := s.v.Load().([]Secret)
+	return secrets
+}
+
+// MatchSecret returns the name of the first secret of p signing the event.
+func MatchSecret(p SecretProvider, e *EventData) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+
+	h := md5.New()
+	for _, s := range p.Secrets() {
+		if verifyHash(h, s.Value, e) {
+			return s.Name, true
+		}
+	}
+	return "", false
+}
+
+// WithSecretProvider verifies the events with any of the secrets of p. The
+// name of the matching secret is added to the context of the handler and of
+// OnError, see SecretName, and with WithStats it is recorded so an old secret
+// can be removed once it is no longer used.
+func WithSecretProvider(p SecretProvider) Option {
+	return func(o *Options) {
+		o.Secrets = p
+		o.Verify = func(data *EventData) bool {
+			_, ok := MatchSecret(p, data)
+			return ok
+		}
+	}
+}
+
+type secretNameKey struct{}
+
+// SecretName returns the name of the secret which signed the event being
+// handled, if it was verified with WithSecretProvider.
+func SecretName(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(secretNameKey{}).(string)
+	return name, ok
+}
+
+// verifySecret matches the event against the secrets of o, and returns ctx
+// with the name of the matching secret.
+func verifySecret(ctx context.Context, o *Options, e *EventData) (context.Context, bool) {
+	name, ok := MatchSecret(o.Secrets, e)
+	if o.Stats {
+		tagName := name
+		if !ok {
+			tagName = "none"
+		}
+		stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(keySecret, tagName)},
+			mVerified.M(1))
+	}
+	if !ok {
+		return ctx, false
+	}
+	return context.WithValue(ctx, secretNameKey{}, name), true
+}
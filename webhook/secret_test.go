@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchSecret(t *testing.T) {
+	e := &EventData{
+		ID:   "c75570bb-dc1a-4192-946c-ed09a34f7d77",
+		Hash: "a173b27d031519da1e0cc5468eb7b9f3",
+	}
+	current := Secret{Name: "current", Value: []byte(`946b9654dcfc55342c55e533805cdba6`)}
+	next := Secret{Name: "next", Value: []byte(`0000000000000000000000000000000`)}
+
+	s := NewSecretSet(next)
+	if _, ok := MatchSecret(s, e); ok {
+		t.Errorf("MatchSecret() ok = true, want false")
+	}
+
+	s.Store(next, current)
+	if name, ok := MatchSecret(s, e); !ok || name != "current" {
+		t.Errorf("MatchSecret() = %q, %v, want %q, true", name, ok, "current")
+	}
+
+	if _, ok := MatchSecret(s, nil); ok {
+		t.Errorf("MatchSecret() without event data ok = true, want false")
+	}
+}
+
+func TestWithSecretProvider(t *testing.T) {
+	e := &EventData{
+		ID:   "c75570bb-dc1a-4192-946c-ed09a34f7d77",
+		Hash: "a173b27d031519da1e0cc5468eb7b9f3",
+	}
+	s := NewSecretSet()
+
+	var o Options
+	WithSecretProvider(s)(&o)
+	if o.Verify(e) {
+		t.Errorf("Verify() = true, want false")
+	}
+
+	s.Store(Secret{Name: "current", Value: []byte(`946b9654dcfc55342c55e533805cdba6`)})
+	if !o.Verify(e) {
+		t.Errorf("Verify() = false after Store, want true")
+	}
+}
+
+func TestNewHTTPHandler_SecretName(t *testing.T) {
+	s := NewSecretSet(Secret{Name: "current", Value: []byte(`946b9654dcfc55342c55e533805cdba6`)})
+
+	var gotName, gotErrName string
+	h := NewHTTPHandler(HandlerFunc(func(ctx context.Context, data *Data) error {
+		gotName, _ = SecretName(ctx)
+		return errors.New("failed")
+	}), WithSecretProvider(s), WithOnError(func(ctx context.Context, err error) {
+		gotErrName, _ = SecretName(ctx)
+	}))
+
+	body := `{"data_type":"log","id":"c75570bb-dc1a-4192-946c-ed09a34f7d77","hash":"a173b27d031519da1e0cc5468eb7b9f3"}`
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("NewHTTPHandler() code = %v, want %v", w.Code, http.StatusInternalServerError)
+	}
+	if gotName != "current" {
+		t.Errorf("SecretName() in handler = %q, want %q", gotName, "current")
+	}
+	if gotErrName != "current" {
+		t.Errorf("SecretName() in OnError = %q, want %q", gotErrName, "current")
+	}
+
+	w = httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"data_type":"log","id":"1","hash":"x"}`)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("NewHTTPHandler() code = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,643 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSpoolClosed is returned by a Spool after Close.
+var ErrSpoolClosed = errors.New("webhook: spool is closed")
+
+const (
+	spoolSegmentExt    = ".seg"
+	spoolCheckpoint    = "checkpoint"
+	spoolDeadLetters   = "deadletters"
+	spoolHeaderSize    = 8
+	spoolMaxBackoff    = time.Minute
+	spoolMaxRecordSize = 64 << 20
+)
+
+type SpoolOptions struct {
+	// Backoff is the delay before handling a failed event again, doubled
+	// after each failure up to a minute.
+	Backoff time.Duration
+
+	// MaxAttempts is the number of times an event is handled before it is
+	// dropped, so an event failing for good doesn't hold back the events
+	// after it. The dropped events are moved to the dead letters of the
+	// spool, see ReadSpoolDeadLetters, and reported to OnError with
+	// ErrEventDropped. The events are retried forever when it is zero.
+	MaxAttempts int
+
+	OnError func(context.Context, error)
+
+	// SegmentSize is the size after which a new segment is started.
+	SegmentSize int64
+}
+
+type SpoolOption = func(*SpoolOptions)
+
+func WithSpoolBackoff(d time.Duration) SpoolOption {
+	return func(o *SpoolOptions) {
+		o.Backoff = d
+	}
+}
+
+func WithSpoolMaxAttempts(n int) SpoolOption {
+	return func(o *SpoolOptions) {
+		o.MaxAttempts = n
+	}
+}
+
+func WithSpoolOnError(fn func(context.Context, error)) SpoolOption {
+	return func(o *SpoolOptions) {
+		o.OnError = fn
+	}
+}
+
+func WithSpoolSegmentSize(n int64) SpoolOption {
+	return func(o *SpoolOptions) {
+		o.SegmentSize = n
+	}
+}
+
+// Spool is a Handler writing the raw payload of the events to a log on disk
+// before they are acknowledged, then handling them in order in the
+// background. The position of the last event handled is checkpointed, so
+// the events not handled yet are handled again after a restart. Segments
+// whose events were all handled are removed.
+//
+// The handler of the spool should handle the events synchronously, an event
+// is checkpointed as soon as the handler returns.
+type Spool struct {
+	dir string
+	fn  Handler
+	o   *SpoolOptions
+
+	mu     sync.Mutex
+	closed bool
+	f      *os.File // The segment being written.
+	seq    uint64
+	size   int64
+
+	// The position of the next event to handle, only used by run.
+	rseq uint64
+	roff int64
+
+	notify chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ Handler = (*Spool)(nil)
+
+// OpenSpool opens or creates the spool in dir, and starts handling the
+// events not checkpointed yet with fn.
+func OpenSpool(dir string, fn Handler, opts ...SpoolOption) (*Spool, error) {
+	o := &SpoolOptions{
+		Backoff:     time.Second,
+		SegmentSize: 16 << 20,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Spool{
+		dir:    dir,
+		fn:     fn,
+		o:      o,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run()
+
+	return s, nil
+}
+
+func (s *Spool) open() error {
+	seqs, err := s.segments()
+	if err != nil {
+		return err
+	}
+	s.rseq, s.roff, err = s.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	if len(seqs) == 0 {
+		if s.rseq == 0 {
+			s.rseq = 1
+		}
+		s.roff = 0
+		s.seq = s.rseq
+		return s.create()
+	}
+	if s.rseq < seqs[0] {
+		s.rseq, s.roff = seqs[0], 0
+	}
+	if err := s.compact(); err != nil {
+		return err
+	}
+
+	// The last record may be torn by a crash while it was written.
+	s.seq = seqs[len(seqs)-1]
+	size, err := validSize(s.path(s.seq))
+	if err != nil {
+		return err
+	}
+	if err := os.Truncate(s.path(s.seq), size); err != nil {
+		return err
+	}
+
+	s.f, err = os.OpenFile(s.path(s.seq), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	s.size = size
+
+	return nil
+}
+
+// ServeWebhook appends the raw payload of the event to the spool, it
+// returns once the payload is on disk.
+func (s *Spool) ServeWebhook(ctx context.Context, data *Data) error {
+	if data.RawData == nil {
+		return errors.New("webhook: spool requires the raw payload of the event")
+	}
+
+	b, err := io.ReadAll(data.RawData)
+	if err != nil {
+		return err
+	}
+	data.RawData = bytes.NewReader(b)
+
+	return s.append(b)
+}
+
+// Close stops accepting events and waits for the spooled events to be
+// handled, or for ctx to be done. The events not handled are kept in the
+// spool for the next time it is opened.
+func (s *Spool) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wake()
+
+	var err error
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f != nil {
+		if cerr := s.f.Close(); err == nil {
+			err = cerr
+		}
+		s.f = nil
+	}
+	return err
+}
+
+func (s *Spool) append(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	n := int64(spoolHeaderSize + len(b))
+	if s.f != nil && s.size > 0 && s.size+n > s.o.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	if s.f == nil {
+		if err := s.create(); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.f.Write(newRecord(b))
+	if err == nil {
+		err = s.f.Sync()
+	}
+	if err != nil {
+		// Drop the partial record, so the next one is appended after the
+		// last complete record.
+		s.f.Truncate(s.size)
+		return err
+	}
+	s.size += n
+
+	s.wake()
+	return nil
+}
+
+// rotate starts a new segment, s.mu must be held.
+func (s *Spool) rotate() error {
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+	}
+	s.seq++
+	return s.create()
+}
+
+func (s *Spool) create() error {
+	f, err := os.OpenFile(s.path(s.seq), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	s.f, s.size = f, 0
+
+	syncDir(s.dir)
+	return nil
+}
+
+func (s *Spool) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Spool) run() {
+	defer close(s.done)
+
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	for {
+		s.mu.Lock()
+		seq, size, closed := s.seq, s.size, s.closed
+		s.mu.Unlock()
+
+		if s.rseq == seq && s.roff >= size {
+			if closed {
+				return
+			}
+			select {
+			case <-s.notify:
+			case <-s.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		if f == nil {
+			var err error
+			f, err = os.Open(s.path(s.rseq))
+			if err != nil {
+				s.onError(err)
+				if !s.sleep(s.o.Backoff) {
+					return
+				}
+				continue
+			}
+		}
+
+		b, err := readRecord(f, s.roff)
+		if err == nil {
+			if !s.deliver(b) {
+				return
+			}
+			s.roff += int64(spoolHeaderSize + len(b))
+		} else {
+			if err != io.EOF {
+				s.onError(fmt.Errorf("webhook: skipping the rest of spool segment %d: %w", s.rseq, err))
+
+				// The writer moves to a new segment, so the events after
+				// the invalid record are not skipped.
+				var rerr error
+				s.mu.Lock()
+				if s.rseq == s.seq {
+					rerr = s.rotate()
+				}
+				s.mu.Unlock()
+				if rerr != nil {
+					s.onError(rerr)
+					if !s.sleep(s.o.Backoff) {
+						return
+					}
+					continue
+				}
+			}
+
+			f.Close()
+			f = nil
+			s.rseq, s.roff = s.rseq+1, 0
+		}
+
+		if err := s.writeCheckpoint(); err != nil {
+			s.onError(err)
+		} else if s.roff == 0 {
+			if err := s.compact(); err != nil {
+				s.onError(err)
+			}
+		}
+	}
+}
+
+// deliver handles the event until it succeeds or it is dropped, it returns
+// false when the spool is closed before.
+func (s *Spool) deliver(b []byte) bool {
+	var data Data
+	if err := json.Unmarshal(b, &data); err != nil {
+		return s.drop(b, fmt.Errorf("%w: %v", ErrEventDropped, err))
+	}
+
+	backoff := s.o.Backoff
+	for attempt := 1; ; attempt++ {
+		data.RawData = bytes.NewReader(b)
+		err := s.fn.ServeWebhook(s.ctx, &data)
+		if err == nil {
+			return true
+		}
+		if s.ctx.Err() != nil {
+			return false
+		}
+		s.onError(err)
+
+		if s.o.MaxAttempts > 0 && attempt >= s.o.MaxAttempts {
+			if data.EventData != nil {
+				return s.drop(b, fmt.Errorf("%w: %s", ErrEventDropped, data.ID))
+			}
+			return s.drop(b, ErrEventDropped)
+		}
+
+		if !s.sleep(backoff) {
+			return false
+		}
+		if backoff *= 2; backoff > spoolMaxBackoff {
+			backoff = spoolMaxBackoff
+		}
+	}
+}
+
+// drop moves the event to the dead letters and reports err, it retries
+// writing the dead letter until it succeeds or the spool is closed.
+func (s *Spool) drop(b []byte, err error) bool {
+	for {
+		werr := s.writeDeadLetter(b)
+		if werr == nil {
+			s.onError(err)
+			return true
+		}
+		s.onError(werr)
+		if !s.sleep(s.o.Backoff) {
+			return false
+		}
+	}
+}
+
+func (s *Spool) writeDeadLetter(b []byte) error {
+	path := filepath.Join(s.dir, spoolDeadLetters)
+	size, err := validSize(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	// Append after the last complete record, dropping a record torn by a
+	// crash.
+	_, err = f.WriteAt(newRecord(b), size)
+	if err == nil {
+		err = f.Truncate(size + int64(spoolHeaderSize+len(b)))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ReadSpoolDeadLetters returns the raw payloads of the events dropped by
+// the spool in dir, see SpoolOptions.MaxAttempts.
+func ReadSpoolDeadLetters(dir string) ([][]byte, error) {
+	f, err := os.Open(filepath.Join(dir, spoolDeadLetters))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var payloads [][]byte
+	var off int64
+	for {
+		b, err := readRecord(f, off)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// A record torn by a crash is overwritten by the next one.
+			return payloads, nil
+		}
+		if err != nil {
+			return payloads, err
+		}
+		payloads = append(payloads, b)
+		off += int64(spoolHeaderSize + len(b))
+	}
+}
+
+func (s *Spool) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+func (s *Spool) onError(err error) {
+	if s.o.OnError != nil {
+		s.o.OnError(context.Background(), err)
+	}
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentExt))
+}
+
+// segments returns the sequence numbers of the segments, in order.
+func (s *Spool) segments() ([]uint64, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		var seq uint64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, spoolSegmentExt), "%d", &seq); err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	return seqs, nil
+}
+
+// compact removes the segments before the checkpoint.
+func (s *Spool) compact() error {
+	seqs, err := s.segments()
+	if err != nil {
+		return err
+	}
+	for _, seq := range seqs {
+		if seq >= s.rseq {
+			break
+		}
+		if err := os.Remove(s.path(seq)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spool) readCheckpoint() (seq uint64, off int64, err error) {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, spoolCheckpoint))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if _, err := fmt.Sscanf(string(b), "%d %d", &seq, &off); err != nil {
+		return 0, 0, fmt.Errorf("webhook: invalid spool checkpoint: %w", err)
+	}
+	return seq, off, nil
+}
+
+func (s *Spool) writeCheckpoint() error {
+	tmp, err := ioutil.TempFile(s.dir, spoolCheckpoint+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := fmt.Fprintf(tmp, "%d %d\n", s.rseq, s.roff); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, spoolCheckpoint))
+}
+
+// newRecord returns the record of the payload b, its size and checksum
+// followed by b.
+func newRecord(b []byte) []byte {
+	rec := make([]byte, spoolHeaderSize+len(b))
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(b)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(b))
+	copy(rec[spoolHeaderSize:], b)
+	return rec
+}
+
+// readRecord reads the record at off, it returns io.EOF at the end of the
+// segment.
+func readRecord(f *os.File, off int64) ([]byte, error) {
+	var h [spoolHeaderSize]byte
+	if _, err := f.ReadAt(h[:], off); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(h[0:4])
+	if n > spoolMaxRecordSize {
+		return nil, fmt.Errorf("invalid record size %d at %d", n, off)
+	}
+	b := make([]byte, n)
+	if _, err := f.ReadAt(b, off+spoolHeaderSize); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(b) != binary.BigEndian.Uint32(h[4:8]) {
+		return nil, fmt.Errorf("invalid record checksum at %d", off)
+	}
+	return b, nil
+}
+
+// validSize returns the size of the complete records at the start of the
+// segment.
+func validSize(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var off int64
+	for {
+		b, err := readRecord(f, off)
+		if err != nil {
+			return off, nil
+		}
+		off += int64(spoolHeaderSize + len(b))
+	}
+}
+
+// syncDir makes the creation of a file durable, where it is supported.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func rawEvent(id string) *Data {
+	return &Data{RawData: strings.NewReader(fmt.Sprintf(`{"data_type":"log","id":%q}`, id))}
+}
+
+// recorder records the IDs of the events, failing while fail is set.
+type recorder struct {
+	mu   sync.Mutex
+	ids  []string
+	fail bool
+}
+
+func (r *recorder) ServeWebhook(ctx context.Context, data *Data) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fail {
+		return errors.New("failed")
+	}
+	r.ids = append(r.ids, data.ID)
+	return nil
+}
+
+func (r *recorder) got() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return strings.Join(r.ids, ",")
+}
+
+func spoolEvents(t *testing.T, s *Spool, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := s.ServeWebhook(context.Background(), rawEvent(id)); err != nil {
+			t.Fatalf("Spool.ServeWebhook() error = %v", err)
+		}
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	r := &recorder{}
+	s, err := OpenSpool(dir, r, WithSpoolSegmentSize(64))
+	if err != nil {
+		t.Fatalf("OpenSpool() error = %v", err)
+	}
+	spoolEvents(t, s, "1", "2", "3", "4", "5")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Spool.Close() error = %v", err)
+	}
+
+	if got := r.got(); got != "1,2,3,4,5" {
+		t.Errorf("Spool handled %v, want 1,2,3,4,5", got)
+	}
+	if err := s.ServeWebhook(context.Background(), rawEvent("6")); !errors.Is(err, ErrSpoolClosed) {
+		t.Errorf("Spool.ServeWebhook() error = %v, want %v", err, ErrSpoolClosed)
+	}
+
+	segs, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	if len(segs) != 1 {
+		t.Errorf("Spool kept segments %v, want only the last one", segs)
+	}
+}
+
+func TestSpool_Recovery(t *testing.T) {
+	dir := t.TempDir()
+	r := &recorder{fail: true}
+	s, err := OpenSpool(dir, r, WithSpoolSegmentSize(64), WithSpoolBackoff(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenSpool() error = %v", err)
+	}
+	spoolEvents(t, s, "1", "2", "3")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Spool.Close() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// A record torn by a crash is dropped.
+	segs, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	f, err := os.OpenFile(segs[len(segs)-1], os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 1})
+	f.Close()
+
+	r.fail = false
+	s, err = OpenSpool(dir, r, WithSpoolSegmentSize(64))
+	if err != nil {
+		t.Fatalf("OpenSpool() error = %v", err)
+	}
+	spoolEvents(t, s, "4")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Spool.Close() error = %v", err)
+	}
+
+	if got := r.got(); got != "1,2,3,4" {
+		t.Errorf("Spool handled %v, want 1,2,3,4", got)
+	}
+}
+
+func TestSpool_MaxAttempts(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	dir := t.TempDir()
+	r := &recorder{fail: true}
+	s, err := OpenSpool(dir, r,
+		WithSpoolBackoff(time.Millisecond),
+		WithSpoolMaxAttempts(2),
+		WithSpoolOnError(func(ctx context.Context, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}))
+	if err != nil {
+		t.Fatalf("OpenSpool() error = %v", err)
+	}
+	spoolEvents(t, s, "1")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Spool.Close() error = %v", err)
+	}
+
+	if len(errs) != 3 || !errors.Is(errs[2], ErrEventDropped) {
+		t.Errorf("Spool OnError = %v, want 2 failures and %v", errs, ErrEventDropped)
+	}
+
+	dead, err := ReadSpoolDeadLetters(dir)
+	if err != nil {
+		t.Fatalf("ReadSpoolDeadLetters() error = %v", err)
+	}
+	if len(dead) != 1 || string(dead[0]) != `{"data_type":"log","id":"1"}` {
+		t.Errorf("ReadSpoolDeadLetters() = %q, want the dropped event", dead)
+	}
+}
+
+func TestSpool_RetriesForever(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		handled  []string
+	)
+	s, err := OpenSpool(t.TempDir(), HandlerFunc(func(ctx context.Context, data *Data) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if data.ID == "1" && attempts < 12 {
+			attempts++
+			return errors.New("failed")
+		}
+		handled = append(handled, data.ID)
+		return nil
+	}), WithSpoolBackoff(100*time.Microsecond))
+	if err != nil {
+		t.Fatalf("OpenSpool() error = %v", err)
+	}
+	spoolEvents(t, s, "1", "2")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Spool.Close() error = %v", err)
+	}
+
+	if got := strings.Join(handled, ","); got != "1,2" {
+		t.Errorf("Spool handled %v, want 1,2", got)
+	}
+}
@@ -13,10 +13,20 @@ import (
 var (
 	mLatencyMs = stats.Float64("latency", "The latency in milliseconds", "ms")
 	mFaces     = stats.Int64("faces_detected", "The number of faces detected", "1")
+	mVerified  = stats.Int64("events_verified", "The number of events verified", "1")
+
+	mQueueDepth    = stats.Int64("queue_depth", "The number of events waiting in the queue", "1")
+	mQueueDropped  = stats.Int64("queue_dropped", "The number of events dropped from the queue", "1")
+	mQueueRejected = stats.Int64("queue_rejected", "The number of events rejected by the queue", "1")
+
+	mForwarded = stats.Int64("events_forwarded", "The number of events forwarded to the sinks", "1")
 
 	keyDeviceID   = tag.MustNewKey("giautm.dev/hanetai/device-id")
 	keyPersonType = tag.MustNewKey("giautm.dev/hanetai/person-type")
 	keyPlaceID    = tag.MustNewKey("giautm.dev/hanetai/place-id")
+	keySecret     = tag.MustNewKey("giautm.dev/hanetai/secret")
+	keySink       = tag.MustNewKey("giautm.dev/hanetai/sink")
+	keyStatus     = tag.MustNewKey("giautm.dev/hanetai/status")
 )
 
 func EnableViews() error {
@@ -39,9 +49,47 @@ func EnableViews() error {
 		Aggregation: view.Count(),
 	}
 
+	// The events verified by each secret, "none" when no secret matched.
+	verifiedCountView := &view.View{
+		Name:        "hanet/events_verified",
+		Measure:     mVerified,
+		Description: "The number of events verified by each secret",
+		TagKeys:     []tag.Key{keySecret},
+		Aggregation: view.Count(),
+	}
+
+	queueDepthView := &view.View{
+		Name:        "hanet/queue_depth",
+		Measure:     mQueueDepth,
+		Description: "The number of events waiting in the queue",
+		Aggregation: view.LastValue(),
+	}
+	queueDroppedView := &view.View{
+		Name:        "hanet/queue_dropped",
+		Measure:     mQueueDropped,
+		Description: "The number of events dropped from the queue",
+		Aggregation: view.Count(),
+	}
+	queueRejectedView := &view.View{
+		Name:        "hanet/queue_rejected",
+		Measure:     mQueueRejected,
+		Description: "The number of events rejected by the queue",
+		Aggregation: view.Count(),
+	}
+
+	// The events forwarded by each sink, "ok" or "failed".
+	forwardedCountView := &view.View{
+		Name:        "hanet/events_forwarded",
+		Measure:     mForwarded,
+		Description: "The number of events forwarded to each sink",
+		TagKeys:     []tag.Key{keySink, keyStatus},
+		Aggregation: view.Count(),
+	}
+
 	// Ensure that they are registered so
 	// that measurements won't be dropped.
-	return view.Register(latencyView, facesDetectedCountView)
+	return view.Register(latencyView, facesDetectedCountView, verifiedCountView,
+		queueDepthView, queueDroppedView, queueRejectedView, forwardedCountView)
 }
 
 func ReportStats(fn Handler) HandlerFunc {
@@ -3,17 +3,34 @@ package webhook
 import (
 	"bytes"
 	"context"
-	"crypto/md5"
 	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"net/http"
 	"os"
+	"time"
 )
 
+// ErrEventTimeSkew is reported to OnError when an event is rejected by
+// WithMaxSkew.
+var ErrEventTimeSkew = errors.New("webhook: event time is outside the allowed window")
+
+// DateLocation is the time zone of EventData.Date, Hanet reports it in
+// Vietnam time.
+var DateLocation = time.FixedZone("ICT", 7*60*60)
+
+// DateLayout is the layout of EventData.Date.
+const DateLayout = "2006-01-02 15:04:05"
+
+// FormatDate formats t as EventData.Date.
+func FormatDate(t time.Time) string {
+	return t.In(DateLocation).Format(DateLayout)
+}
+
 type ActionType string
 
 const (
@@ -36,6 +53,13 @@ type Data struct {
 	RawData  io.Reader `json:"-"`
 	DataType DataType  `json:"data_type"`
 
+	// Image is the detected image, when it is sent along the event or kept
+	// by ArchiveImages.
+	Image []byte `json:"-"`
+
+	// ImagePath is where ArchiveImages stored the detected image.
+	ImagePath string `json:"-"`
+
 	*EventData
 	*DeviceData
 	*PersonData
@@ -116,9 +140,13 @@ func (fn HandlerFunc) ServeWebhook(ctx context.Context, data *Data) error {
 }
 
 type Options struct {
+	MaxSkew time.Duration
 	OnError func(context.Context, error)
 	Stats   bool
 	Verify  func(*EventData) bool
+
+	// Secrets verifies the events instead of Verify when it is set.
+	Secrets SecretProvider
 }
 
 type Option = func(*Options)
@@ -132,10 +160,19 @@ func WithSecretVerify(secret []byte) Option {
 		}
 	}
 
+	return WithSecretProvider(NewSecretSet(Secret{Name: "default", Value: secret}))
+}
+
+// WithMaxSkew rejects the events whose Time, or Date when Time is not set, is
+// more than d away from now. The events with neither, such as some device,
+// person and place changes, are not checked.
+//
+// The hash only covers the secret and the event ID, not the time, so the
+// window doesn't authenticate it: a replayed event with its time rewritten
+// passes the check. Dedup skips the replays of the IDs it still keeps.
+func WithMaxSkew(d time.Duration) Option {
 	return func(o *Options) {
-		o.Verify = func(data *EventData) bool {
-			return verifyHash(md5.New(), secret, data)
-		}
+		o.MaxSkew = d
 	}
 }
 
@@ -187,17 +224,37 @@ func NewHTTPHandler(fn Handler, opts ...Option) http.HandlerFunc {
 			return
 		}
 
-		if !o.Verify(data.EventData) {
+		ok := false
+		if o.Secrets != nil {
+			ctx, ok = verifySecret(ctx, o, data.EventData)
+		} else {
+			ok = o.Verify(data.EventData)
+		}
+		if !ok {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
 
+		if o.MaxSkew > 0 {
+			if err := checkSkew(data.EventData, time.Now(), o.MaxSkew); err != nil {
+				if o.OnError != nil {
+					o.OnError(ctx, err)
+				}
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+
 		data.RawData = &rawData
 		if err := fn.ServeWebhook(ctx, &data); err != nil {
 			if o.OnError != nil {
 				o.OnError(ctx, err)
 			}
-			w.WriteHeader(http.StatusInternalServerError)
+			if errors.Is(err, ErrQueueFull) || errors.Is(err, ErrQueueClosed) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
 			return
 		}
 
@@ -216,3 +273,32 @@ func verifyHash(h hash.Hash, secret []byte, e *EventData) bool {
 
 	return subtle.ConstantTimeCompare([]byte(e.Hash), dst) == 1
 }
+
+// EventTime returns the time of the event, from Time or Date when Time is
+// not set.
+func (e *EventData) EventTime() (time.Time, bool) {
+	if e == nil {
+		return time.Time{}, false
+	}
+	if e.Time > 0 {
+		return time.Unix(0, int64(e.Time)*int64(time.Millisecond)), true
+	}
+
+	t, err := time.ParseInLocation(DateLayout, e.Date, DateLocation)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func checkSkew(e *EventData, now time.Time, max time.Duration) error {
+	t, ok := e.EventTime()
+	if !ok {
+		return nil
+	}
+
+	if d := now.Sub(t); d > max || d < -max {
+		return fmt.Errorf("%w: event %s is %v away", ErrEventTimeSkew, e.ID, d.Round(time.Second))
+	}
+	return nil
+}
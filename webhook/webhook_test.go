@@ -1,9 +1,16 @@
 package webhook
 
 import (
+	"context"
 	"crypto/md5"
+	"errors"
+	"fmt"
 	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_verifyHash(t *testing.T) {
@@ -80,3 +87,93 @@ func TestIntID_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func Test_checkSkew(t *testing.T) {
+	now := time.Date(2021, 7, 26, 3, 50, 9, 0, time.UTC)
+	ms := func(t time.Time) uint64 {
+		return uint64(t.UnixNano() / int64(time.Millisecond))
+	}
+	tests := []struct {
+		name    string
+		e       *EventData
+		wantErr bool
+	}{
+		{
+			name: "time within window",
+			e:    &EventData{Time: ms(now.Add(-time.Minute))},
+		},
+		{
+			name:    "time too old",
+			e:       &EventData{Time: ms(now.Add(-10 * time.Minute))},
+			wantErr: true,
+		},
+		{
+			name:    "time in the future",
+			e:       &EventData{Time: ms(now.Add(10 * time.Minute))},
+			wantErr: true,
+		},
+		{
+			name: "date in Vietnam time",
+			e:    &EventData{Date: "2021-07-26 10:48:09"},
+		},
+		{
+			name:    "date too old",
+			e:       &EventData{Date: "2021-07-26 03:50:09"},
+			wantErr: true,
+		},
+		{
+			name:    "missing time",
+			e:       &EventData{ActionType: ActionUpdate},
+			wantErr: false,
+		},
+		{
+			name:    "missing event data",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSkew(tt.e, now, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSkew() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrEventTimeSkew) {
+				t.Errorf("checkSkew() error = %v, want %v", err, ErrEventTimeSkew)
+			}
+		})
+	}
+}
+
+func TestNewHTTPHandler_MaxSkew(t *testing.T) {
+	var gotErr error
+	h := NewHTTPHandler(HandlerFunc(func(ctx context.Context, data *Data) error {
+		return nil
+	}), WithMaxSkew(time.Minute), WithOnError(func(ctx context.Context, err error) {
+		gotErr = err
+	}))
+
+	tests := []struct {
+		name     string
+		time     time.Time
+		wantCode int
+		wantErr  error
+	}{
+		{name: "fresh", time: time.Now(), wantCode: http.StatusOK},
+		{name: "replayed", time: time.Now().Add(-time.Hour), wantCode: http.StatusForbidden, wantErr: ErrEventTimeSkew},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr = nil
+			body := fmt.Sprintf(`{"data_type":"log","id":"1","time":%d}`, tt.time.UnixNano()/int64(time.Millisecond))
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("NewHTTPHandler() code = %v, want %v", w.Code, tt.wantCode)
+			}
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Errorf("NewHTTPHandler() OnError = %v, want %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
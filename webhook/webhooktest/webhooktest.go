@@ -0,0 +1,291 @@
+// Package webhooktest builds and sends signed webhook events, to test the
+// webhook receivers end to end without Hanet.
+package webhooktest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"giautm.dev/hanetai/webhook"
+)
+
+// Hash returns the hash of the event ID signed with secret, as checked by
+// webhook.WithSecretVerify.
+func Hash(secret []byte, id string) string {
+	h := md5.New()
+	h.Write(secret)
+	h.Write([]byte(id))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sign sets the hash of the event, setting a new ID first when it has none.
+func Sign(data *webhook.Data, secret []byte) {
+	if data.EventData == nil {
+		data.EventData = &webhook.EventData{}
+	}
+	if data.ID == "" {
+		data.ID = NewID()
+	}
+	data.Hash = Hash(secret, data.ID)
+}
+
+// NewID returns a random event ID, formatted as the UUIDs of Hanet.
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// NewEvent returns an event of the data type and action, with a new ID, the
+// current time, and a sample place, device and person.
+func NewEvent(dataType webhook.DataType, action webhook.ActionType) *webhook.Data {
+	now := time.Now()
+	data := &webhook.Data{
+		DataType: dataType,
+		EventData: &webhook.EventData{
+			ActionType: action,
+			Date:       webhook.FormatDate(now),
+			ID:         NewID(),
+			Time:       uint64(now.UnixNano() / int64(time.Millisecond)),
+		},
+		PlaceData: &webhook.PlaceData{
+			PlaceID:   1542,
+			PlaceName: "Hanet HQ",
+		},
+	}
+
+	device := &webhook.DeviceData{
+		DeviceID:   "C21024B155",
+		DeviceName: "Front door",
+	}
+	person := &webhook.PersonData{
+		AliasID:          "VCFL1231231",
+		DetectedImageURL: "https://statics.hanet.ai/face/employee/1542/VCFL1231231.jpg",
+		PersonID:         "1858497629510868990",
+		PersonName:       "Nguyễn Văn A",
+		PersonType:       webhook.PersonEmployee,
+	}
+
+	switch dataType {
+	case webhook.DataLog, webhook.DataCheckinPicture:
+		data.DeviceData = device
+		data.PersonData = person
+	case webhook.DataPerson:
+		data.PersonData = person
+		data.DetectedImageURL = ""
+	case webhook.DataDevice:
+		data.DeviceData = device
+	}
+	return data
+}
+
+// Sender posts signed events to a webhook receiver.
+type Sender struct {
+	URL    string
+	Secret []byte
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Send signs and posts the event, it fails unless the receiver replies with
+// a 2xx status.
+func (s *Sender) Send(ctx context.Context, data *webhook.Data) error {
+	Sign(data, s.Secret)
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.post(ctx, b)
+}
+
+func (s *Sender) post(ctx context.Context, b []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// StatusError is the status of a receiver rejecting an event.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhooktest: receiver replied %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Result is the outcome of sending an event.
+type Result struct {
+	ID       string
+	Duration time.Duration
+	Err      error
+}
+
+// Burst sends n events built by fn, with concurrency requests at the same
+// time, and returns their results in order.
+func (s *Sender) Burst(ctx context.Context, n, concurrency int, fn func(i int) *webhook.Data) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			data := fn(i)
+			start := time.Now()
+			err := s.Send(ctx, data)
+			results[i] = Result{ID: data.ID, Duration: time.Since(start), Err: err}
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Step is a line of a script, see Sender.Script.
+type Step struct {
+	// Delay is waited before sending the event, e.g. "500ms".
+	Delay string `json:"delay,omitempty"`
+
+	// Repeat sends the event this many times, with a new ID each time
+	// unless the event has one.
+	Repeat int `json:"repeat,omitempty"`
+
+	// Event is the payload of the event. The ID, Time, Date and hash are
+	// set when they are missing.
+	Event json.RawMessage `json:"event"`
+}
+
+// Script sends the events of a JSONL script, one Step per line:
+//
+//	{"event":{"data_type":"log","personName":"Nguyễn Văn A","placeID":1542}}
+//	{"delay":"2s","repeat":3,"event":{"data_type":"person","action_type":"delete","aliasID":"VCFL1231231"}}
+//
+// fn is called with the result of each event, the script stops at the
+// first error fn returns.
+func (s *Sender) Script(ctx context.Context, r io.Reader, fn func(Result) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		var step Step
+		if err := json.Unmarshal(sc.Bytes(), &step); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		var delay time.Duration
+		if step.Delay != "" {
+			d, err := time.ParseDuration(step.Delay)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			delay = d
+		}
+		if step.Repeat < 1 {
+			step.Repeat = 1
+		}
+
+		for i := 0; i < step.Repeat; i++ {
+			if delay > 0 {
+				t := time.NewTimer(delay)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				}
+			}
+
+			b, id, err := s.prepare(step.Event)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+
+			start := time.Now()
+			err = s.post(ctx, b)
+			if err := fn(Result{ID: id, Duration: time.Since(start), Err: err}); err != nil {
+				return err
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// prepare fills in the missing event fields of the payload and signs it,
+// keeping the fields unknown to webhook.Data.
+func (s *Sender) prepare(payload json.RawMessage) ([]byte, string, error) {
+	fields := map[string]interface{}{}
+	if len(payload) > 0 {
+		d := json.NewDecoder(bytes.NewReader(payload))
+		d.UseNumber()
+		if err := d.Decode(&fields); err != nil {
+			return nil, "", err
+		}
+	}
+
+	now := time.Now()
+	id, _ := fields["id"].(string)
+	if id == "" {
+		id = NewID()
+		fields["id"] = id
+	}
+	if _, ok := fields["time"]; !ok {
+		fields["time"] = now.UnixNano() / int64(time.Millisecond)
+	}
+	if _, ok := fields["date"]; !ok {
+		fields["date"] = webhook.FormatDate(now)
+	}
+	if _, ok := fields["hash"]; !ok {
+		fields["hash"] = Hash(s.Secret, id)
+	}
+
+	b, err := json.Marshal(fields)
+	return b, id, err
+}
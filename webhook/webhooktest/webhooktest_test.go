@@ -0,0 +1,120 @@
+package webhooktest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"giautm.dev/hanetai/webhook"
+)
+
+var secret = []byte("946b9654dcfc55342c55e533805cdba6")
+
+func newReceiver(t *testing.T) (*httptest.Server, func() []*webhook.Data) {
+	var (
+		mu     sync.Mutex
+		events []*webhook.Data
+	)
+	srv := httptest.NewServer(webhook.NewHTTPHandler(webhook.HandlerFunc(func(ctx context.Context, data *webhook.Data) error {
+		mu.Lock()
+		events = append(events, data)
+		mu.Unlock()
+		return nil
+	}), webhook.WithSecretVerify(secret)))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []*webhook.Data {
+		mu.Lock()
+		defer mu.Unlock()
+		return events
+	}
+}
+
+func TestHash(t *testing.T) {
+	got := Hash(secret, "c75570bb-dc1a-4192-946c-ed09a34f7d77")
+	if want := "a173b27d031519da1e0cc5468eb7b9f3"; got != want {
+		t.Errorf("Hash() = %v, want %v", got, want)
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	srv, events := newReceiver(t)
+	ctx := context.Background()
+
+	s := &Sender{URL: srv.URL, Secret: secret}
+	for _, dt := range []webhook.DataType{webhook.DataLog, webhook.DataPerson, webhook.DataPlace, webhook.DataDevice} {
+		if err := s.Send(ctx, NewEvent(dt, webhook.ActionAdd)); err != nil {
+			t.Errorf("Sender.Send(%v) error = %v", dt, err)
+		}
+	}
+	if got := len(events()); got != 4 {
+		t.Errorf("receiver got %v events, want 4", got)
+	}
+	if e, ok := events()[0].Event().(*webhook.CheckinEvent); !ok || e.PersonName == "" {
+		t.Errorf("receiver got %#v, want a check-in", events()[0].Event())
+	}
+
+	s.Secret = []byte("wrong")
+	var serr *StatusError
+	if err := s.Send(ctx, NewEvent(webhook.DataLog, "")); !errors.As(err, &serr) || serr.StatusCode != http.StatusForbidden {
+		t.Errorf("Sender.Send() error = %v, want 403", err)
+	}
+}
+
+func TestSender_Burst(t *testing.T) {
+	srv, events := newReceiver(t)
+
+	s := &Sender{URL: srv.URL, Secret: secret}
+	results := s.Burst(context.Background(), 10, 3, func(i int) *webhook.Data {
+		return NewEvent(webhook.DataLog, "")
+	})
+	for _, r := range results {
+		if r.Err != nil || r.ID == "" {
+			t.Errorf("Sender.Burst() result = %+v", r)
+		}
+	}
+	if got := len(events()); got != 10 {
+		t.Errorf("receiver got %v events, want 10", got)
+	}
+}
+
+func TestSender_Script(t *testing.T) {
+	srv, events := newReceiver(t)
+
+	script := `{"event":{"data_type":"log","personName":"Nguyễn Văn A","placeID":1542}}
+
+{"delay":"1ms","repeat":2,"event":{"data_type":"person","action_type":"delete","aliasID":"VCFL1231231"}}
+{"event":{"data_type":"door","state":"open"}}
+`
+	s := &Sender{URL: srv.URL, Secret: secret}
+	var results []Result
+	err := s.Script(context.Background(), strings.NewReader(script), func(r Result) error {
+		results = append(results, r)
+		return r.Err
+	})
+	if err != nil {
+		t.Fatalf("Sender.Script() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("Sender.Script() sent %v events, want 4", len(results))
+	}
+
+	got := events()
+	if len(got) != 4 {
+		t.Fatalf("receiver got %v events, want 4", len(got))
+	}
+	if got[1].ID == got[2].ID {
+		t.Errorf("repeated events have the same ID %v", got[1].ID)
+	}
+	if e, ok := got[3].Event().(*webhook.UnknownEvent); !ok || !strings.Contains(string(e.Raw), `"state":"open"`) {
+		t.Errorf("receiver got %#v, want the unknown event", got[3].Event())
+	}
+
+	if err := s.Script(context.Background(), strings.NewReader("{"), func(Result) error { return nil }); err == nil {
+		t.Errorf("Sender.Script() error = nil, want error")
+	}
+}